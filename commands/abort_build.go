@@ -1,16 +1,24 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strconv"
 
+	"github.com/concourse/atc"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/rc"
+	"github.com/concourse/go-concourse/concourse"
+	"github.com/vito/go-interact/interact"
 )
 
 type AbortBuildCommand struct {
-	Job   flaghelpers.JobFlag `short:"j" long:"job"   required:"true" value-name:"PIPELINE/JOB"   description:"Name of a job to cancel"`
-	Build string              `short:"b" long:"build" required:"true" description:"Name of the build to cancel"`
+	Job             flaghelpers.JobFlag `short:"j" long:"job"      value-name:"PIPELINE/JOB" description:"Name of a job to cancel"`
+	Build           string              `short:"b" long:"build"                              description:"Name of the job build to cancel, or the ID of a build if no job is given"`
+	Pipeline        string              `short:"p" long:"pipeline"                           description:"Pipeline whose running builds should be cancelled (requires --all-running)"`
+	AllRunning      bool                `          long:"all-running"                        description:"Cancel every running build of the given job or pipeline"`
+	SkipInteractive bool                `short:"n" long:"non-interactive"                    description:"Cancel the builds without confirmation"`
 }
 
 func (command *AbortBuildCommand) Execute([]string) error {
@@ -23,19 +31,153 @@ func (command *AbortBuildCommand) Execute([]string) error {
 		return err
 	}
 
-	build, exists, err := client.JobBuild(command.Job.PipelineName, command.Job.JobName, command.Build)
-	if err != nil {
-		return fmt.Errorf("failed to get job build")
+	if command.AllRunning {
+		return command.abortAllRunning(client)
+	}
+
+	if command.Pipeline != "" {
+		return errors.New("--pipeline can only be used with --all-running")
+	}
+
+	if command.Build == "" {
+		return errors.New("a build must be specified with --build, or use --all-running")
 	}
 
-	if !exists {
-		return fmt.Errorf("job build does not exist")
+	var build atc.Build
+	var exists bool
+	if command.Job.JobName != "" {
+		build, exists, err = client.JobBuild(command.Job.PipelineName, command.Job.JobName, command.Build)
+		if err != nil {
+			return fmt.Errorf("failed to get job build: %s", err)
+		}
+
+		if !exists {
+			return errors.New("job build does not exist")
+		}
+	} else {
+		build, exists, err = client.Build(command.Build)
+		if err != nil {
+			return fmt.Errorf("failed to get build: %s", err)
+		}
+
+		if !exists {
+			return errors.New("build does not exist")
+		}
 	}
 
 	if err := client.AbortBuild(strconv.Itoa(build.ID)); err != nil {
-		return fmt.Errorf("failed to abort build")
+		return fmt.Errorf("failed to abort build: %s", err)
 	}
 
 	fmt.Println("build successfully aborted")
 	return nil
 }
+
+func (command *AbortBuildCommand) abortAllRunning(client concourse.Client) error {
+	if command.Build != "" {
+		return errors.New("--build cannot be used with --all-running")
+	}
+
+	var jobs []flaghelpers.JobFlag
+	if command.Job.JobName != "" {
+		if command.Pipeline != "" {
+			return errors.New("--job and --pipeline cannot be used together")
+		}
+
+		jobs = append(jobs, command.Job)
+	} else if command.Pipeline != "" {
+		config, _, _, found, err := client.PipelineConfig(command.Pipeline)
+		if err != nil {
+			return fmt.Errorf("failed to get pipeline config: %s", err)
+		}
+
+		if !found {
+			return errors.New("pipeline does not exist")
+		}
+
+		for _, job := range config.Jobs {
+			jobs = append(jobs, flaghelpers.JobFlag{
+				PipelineName: command.Pipeline,
+				JobName:      job.Name,
+			})
+		}
+	} else {
+		return errors.New("--all-running requires either --job or --pipeline")
+	}
+
+	builds := []atc.Build{}
+	for _, job := range jobs {
+		jobBuilds, err := runningJobBuilds(client, job)
+		if err != nil {
+			return err
+		}
+
+		builds = append(builds, jobBuilds...)
+	}
+
+	if len(builds) == 0 {
+		fmt.Println("no running builds")
+		return nil
+	}
+
+	fmt.Println("the following builds will be aborted:")
+	for _, build := range builds {
+		fmt.Printf("  %s/%s #%s (id %d, %s)\n", build.PipelineName, build.JobName, build.Name, build.ID, build.Status)
+	}
+	fmt.Println("")
+
+	confirm := command.SkipInteractive
+	if !confirm {
+		err := interact.NewInteraction("abort these builds?").Resolve(&confirm)
+		if err != nil || !confirm {
+			fmt.Println("bailing out")
+			return err
+		}
+	}
+
+	failed := 0
+	for _, build := range builds {
+		err := client.AbortBuild(strconv.Itoa(build.ID))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to abort build %d: %s\n", build.ID, err)
+			failed++
+			continue
+		}
+
+		fmt.Printf("aborted %s/%s #%s\n", build.PipelineName, build.JobName, build.Name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to abort %d of %d builds", failed, len(builds))
+	}
+
+	return nil
+}
+
+func runningJobBuilds(client concourse.Client, job flaghelpers.JobFlag) ([]atc.Build, error) {
+	running := []atc.Build{}
+
+	page := &concourse.Page{Limit: 100}
+	for page != nil {
+		builds, pagination, found, err := client.JobBuilds(job.PipelineName, job.JobName, *page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get builds for %s/%s: %s", job.PipelineName, job.JobName, err)
+		}
+
+		if !found {
+			return nil, fmt.Errorf("job %s/%s does not exist", job.PipelineName, job.JobName)
+		}
+
+		for _, build := range builds {
+			if build.Status == "pending" || build.Status == "started" {
+				running = append(running, build)
+			}
+		}
+
+		// builds of a job that is not serial finish in any order, so an older
+		// build may still be running on any of the pages that follow
+		page = pagination.Next
+	}
+
+	return running, nil
+}
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"io/ioutil"
+
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/template"
+)
+
+type DecryptVarsCommand struct {
+	File    flaghelpers.PathFlag `short:"f" long:"file" required:"true" description:"Encrypted variables file to decrypt"`
+	Output  string               `short:"o" long:"output"               description:"Write the result to this file instead of stdout (may be the same as --file)"`
+	KeyFile flaghelpers.PathFlag `short:"k" long:"key-file"             description:"File containing the passphrase (defaults to $FLY_VARS_PASSPHRASE, then $FLY_VARS_KEY_FILE, then prompting)"`
+}
+
+func (command *DecryptVarsCommand) Execute([]string) error {
+	contents, err := ioutil.ReadFile(string(command.File))
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := varsPassphrase(command.KeyFile, false)
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := template.DecryptVariables(contents, passphrase)
+	if err != nil {
+		return err
+	}
+
+	return writeVarsOutput(command.Output, decrypted)
+}
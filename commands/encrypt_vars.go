@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/template"
+	"github.com/vito/go-interact/interact"
+)
+
+type EncryptVarsCommand struct {
+	File    flaghelpers.PathFlag `short:"f" long:"file" required:"true" description:"Variables file to encrypt"`
+	Output  string               `short:"o" long:"output"               description:"Write the result to this file instead of stdout (may be the same as --file)"`
+	KeyFile flaghelpers.PathFlag `short:"k" long:"key-file"             description:"File containing the passphrase (defaults to $FLY_VARS_PASSPHRASE, then $FLY_VARS_KEY_FILE, then prompting)"`
+}
+
+func (command *EncryptVarsCommand) Execute([]string) error {
+	contents, err := ioutil.ReadFile(string(command.File))
+	if err != nil {
+		return err
+	}
+
+	if template.IsEncrypted(contents) {
+		return errors.New("variables file is already encrypted")
+	}
+
+	_, err = template.ParseVariables(contents)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := varsPassphrase(command.KeyFile, true)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := template.EncryptVariables(contents, passphrase)
+	if err != nil {
+		return err
+	}
+
+	return writeVarsOutput(command.Output, encrypted)
+}
+
+// varsPassphrase returns the given passphrase for encrypted variables, or
+// else prompts for one.
+func varsPassphrase(keyFile flaghelpers.PathFlag, confirm bool) ([]byte, error) {
+	passphrase, err := givenVarsPassphrase(keyFile)
+	if err != nil || passphrase != nil {
+		return passphrase, err
+	}
+
+	var interactivePassphrase interact.Password
+	err = interact.NewInteraction("passphrase").Resolve(interact.Required(&interactivePassphrase))
+	if err != nil {
+		return nil, err
+	}
+
+	if confirm {
+		var confirmation interact.Password
+		err = interact.NewInteraction("confirm passphrase").Resolve(interact.Required(&confirmation))
+		if err != nil {
+			return nil, err
+		}
+
+		if confirmation != interactivePassphrase {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+
+	return []byte(interactivePassphrase), nil
+}
+
+// givenVarsPassphrase returns the passphrase for encrypted variables read
+// from the key file, or else from $FLY_VARS_PASSPHRASE or the key file named
+// by $FLY_VARS_KEY_FILE, and nil if there is none.
+func givenVarsPassphrase(keyFile flaghelpers.PathFlag) ([]byte, error) {
+	if keyFile != "" {
+		return template.ReadKeyFile(string(keyFile))
+	}
+
+	if passphrase := os.Getenv("FLY_VARS_PASSPHRASE"); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	if keyFile := os.Getenv("FLY_VARS_KEY_FILE"); keyFile != "" {
+		return template.ReadKeyFile(keyFile)
+	}
+
+	return nil, nil
+}
+
+func writeVarsOutput(output string, contents []byte) error {
+	if output == "" {
+		_, err := os.Stdout.Write(contents)
+		return err
+	}
+
+	return ioutil.WriteFile(output, contents, 0600)
+}
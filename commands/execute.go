@@ -1,29 +1,59 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
 	"github.com/concourse/fly/commands/internal/executehelpers"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/config"
 	"github.com/concourse/fly/eventstream"
 	"github.com/concourse/fly/rc"
 	"github.com/concourse/go-concourse/concourse"
+	"gopkg.in/yaml.v2"
 )
 
+const (
+	// exit codes 1-3 are used for failed, errored and aborted builds
+	inputsFailedExitCode  = 4
+	outputsFailedExitCode = 5
+	timedOutExitCode      = 6
+)
+
+const watchFilesInterval = time.Second
+
 type ExecuteCommand struct {
-	TaskConfig     flaghelpers.PathFlag         `short:"c" long:"config" required:"true"                description:"The task config to execute"`
-	Privileged     bool                         `short:"p" long:"privileged"                            description:"Run the task with full privileges"`
-	ExcludeIgnored bool                         `short:"x" long:"exclude-ignored"                       description:"Skip uploading .gitignored paths. This uses the file paths that are in your Git index. Make sure it's up to date!"`
-	Inputs         []flaghelpers.InputPairFlag  `short:"i" long:"input"       value-name:"NAME=PATH"    description:"An input to provide to the task (can be specified multiple times)"`
-	InputsFrom     flaghelpers.JobFlag          `short:"j" long:"inputs-from" value-name:"PIPELINE/JOB" description:"A job to base the inputs on"`
-	Outputs        []flaghelpers.OutputPairFlag `short:"o" long:"output"      value-name:"NAME=PATH"    description:"An output to fetch from the task (can be specified multiple times)"`
-	Tags           []string                     `          long:"tag"         value-name:"TAG"          description:"A tag for a specific environment (can be specified multiple times)"`
+	TaskConfigs      []flaghelpers.PathFlag         `short:"c" long:"config"                                            description:"The task config to execute (can be specified multiple times to run the tasks in order, each with the outputs of the ones before it)"`
+	Task             string                         `          long:"task"              value-name:"STEP"               description:"Execute the task step of the --inputs-from job instead of a task config"`
+	Image            string                         `          long:"image"             value-name:"REF"                description:"Run the task with the given Docker image or rootfs URI instead of its configured image"`
+	ImageDir         flaghelpers.PathFlag           `          long:"image-dir"         value-name:"PATH"               description:"Upload the given image directory, containing rootfs/ and metadata.json, and run the task with it as its image"`
+	Privileged       bool                           `short:"p" long:"privileged"                                        description:"Run the task with full privileges"`
+	ExcludeIgnored   bool                           `short:"x" long:"exclude-ignored"                                   description:"Skip uploading .gitignored paths. This uses the file paths that are in your Git index. Make sure it's up to date!"`
+	Exclude          []string                       `          long:"exclude"           value-name:"GLOB"               description:"Skip uploading input paths matching the pattern, in addition to those in .flyignore (can be specified multiple times)"`
+	Include          []string                       `          long:"include"           value-name:"GLOB"               description:"Upload input paths matching the pattern even if they are otherwise excluded (can be specified multiple times)"`
+	Inputs           []flaghelpers.InputPairFlag    `short:"i" long:"input"             value-name:"NAME=PATH"          description:"An input to provide to the task (can be specified multiple times)"`
+	InputsFrom       flaghelpers.JobFlag            `short:"j" long:"inputs-from"       value-name:"PIPELINE/JOB"       description:"A job to base the inputs on"`
+	InputsFromBuild  flaghelpers.JobBuildFlag       `          long:"inputs-from-build" value-name:"PIPELINE/JOB/BUILD" description:"A build of a job to base the inputs on, using the exact versions it ran with"`
+	Outputs          []flaghelpers.OutputPairFlag   `short:"o" long:"output"            value-name:"NAME=PATH"          description:"An output to fetch from the task, into a directory, a .tgz or .tar archive, or - for a tar on stdout (can be specified multiple times)"`
+	CleanOutputs     bool                           `          long:"clean-outputs"                                     description:"Empty the output directories before fetching the outputs into them"`
+	Params           []flaghelpers.VariablePairFlag `          long:"param"             value-name:"KEY=VALUE"          description:"A param to set on the task, overriding its value in the config and environment (can be specified multiple times)"`
+	ParamsFrom       []flaghelpers.PathFlag         `          long:"params-from"       value-name:"FILE"               description:"A dotenv or YAML file of params to set on the task (can be specified multiple times)"`
+	Timeout          time.Duration                  `          long:"timeout"           value-name:"DURATION"           description:"Abort the build if it runs for longer than the given duration"`
+	Attempts         int                            `          long:"attempts"          value-name:"N"      default:"1" description:"Run the task up to the given number of times until it succeeds"`
+	Tags             []string                       `          long:"tag"               value-name:"TAG"                description:"A tag for a specific environment (can be specified multiple times)"`
+	NonDeterministic bool                           `          long:"non-deterministic"                                 description:"Archive inputs with their original ownership, timestamps and order rather than reproducibly"`
+	WatchFiles       bool                           `          long:"watch-files"                                       description:"Start the build over whenever the local inputs change"`
+	DryRun           bool                           `          long:"dry-run"                                           description:"Print the build plan instead of running it"`
+	JSON             bool                           `          long:"json"                                              description:"Print the build plan as JSON instead of YAML (with --dry-run)"`
 }
 
 func (command *ExecuteCommand) Execute(args []string) error {
@@ -36,71 +66,120 @@ func (command *ExecuteCommand) Execute(args []string) error {
 		return err
 	}
 
-	taskConfigFile := command.TaskConfig
-	excludeIgnored := command.ExcludeIgnored
+	if command.DryRun {
+		return command.dryRun(client, args)
+	}
+
+	if command.WatchFiles {
+		return command.watchFiles(client, args)
+	}
 
-	taskConfig, err := config.LoadTaskConfig(string(taskConfigFile), args)
+	build, finished, err := command.start(client, args)
 	if err != nil {
 		return err
 	}
 
-	inputs, err := executehelpers.DetermineInputs(
-		client,
-		taskConfig.Inputs,
-		command.Inputs,
-		command.InputsFrom,
-	)
+	terminate := make(chan os.Signal, 1)
+
+	go abortOnSignal(client, terminate, build)
+
+	signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case exitCode := <-finished:
+		os.Exit(exitCode)
+
+	case <-command.timeout():
+		command.abortTimedOut(client, build)
+		<-finished
+		os.Exit(timedOutExitCode)
+	}
+
+	return nil
+}
+
+// start creates the one-off build and streams its inputs, outputs and
+// events. The returned channel yields the exit code once all of them are done.
+func (command *ExecuteCommand) start(client concourse.Client, args []string) (atc.Build, <-chan int, error) {
+	excludeIgnored := command.ExcludeIgnored
+
+	prepared, err := command.prepare(client, args)
 	if err != nil {
-		return err
+		return atc.Build{}, nil, err
 	}
 
-	outputs, err := executehelpers.DetermineOutputs(
-		client,
-		taskConfig.Outputs,
-		command.Outputs,
-	)
+	err = executehelpers.InputMappingTable(prepared.inputs).Render(os.Stderr)
 	if err != nil {
-		return err
+		return atc.Build{}, nil, err
 	}
 
+	inputs := prepared.inputs
+	outputs := prepared.outputs
+
 	build, err := executehelpers.CreateBuild(
 		client,
-		command.Privileged,
+		prepared.privileged,
 		inputs,
 		outputs,
-		taskConfig,
-		command.Tags,
+		prepared.tasks,
+		prepared.tags,
+		command.Attempts,
 		Fly.Target,
 	)
 	if err != nil {
-		return err
+		return atc.Build{}, nil, err
 	}
 
-	fmt.Println("executing build", build.ID)
+	// keep stdout clean for the output that is streamed to it
+	logs := os.Stdout
+	if executehelpers.WritesToStdout(outputs) {
+		logs = os.Stderr
+	}
 
-	terminate := make(chan os.Signal, 1)
+	fmt.Fprintln(logs, "executing build", build.ID)
 
-	go abortOnSignal(client, terminate, build)
+	// nothing is streamed until the events are, so that a build whose events
+	// can not be streamed is aborted without leaving uploads behind
+	eventSource, err := client.BuildEvents(fmt.Sprintf("%d", build.ID))
+	if err != nil {
+		abortBuild(client, build)
+		return atc.Build{}, nil, err
+	}
 
-	signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+	progress := executehelpers.NewProgressRenderer(os.Stderr)
 
-	inputChan := make(chan interface{})
+	inputChan := make(chan error, 1)
 	go func() {
 		for _, i := range inputs {
 			if i.Path != "" {
-				executehelpers.Upload(client, i, excludeIgnored)
+				err := executehelpers.Upload(client, i, excludeIgnored, command.Exclude, command.Include, command.deterministic(i), progress)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "failed to upload input `%s`: %s\n", i.Name, err)
+
+					abortErr := client.AbortBuild(strconv.Itoa(build.ID))
+					if abortErr != nil {
+						fmt.Fprintln(os.Stderr, "failed to abort:", abortErr)
+					}
+
+					inputChan <- err
+					return
+				}
 			}
 		}
 		close(inputChan)
 	}()
 
-	var outputChans []chan (interface{})
+	var outputChans []chan error
 	if len(outputs) > 0 {
 		for i, output := range outputs {
-			outputChans = append(outputChans, make(chan interface{}, 1))
-			go func(o executehelpers.Output, outputChan chan<- interface{}) {
+			outputChans = append(outputChans, make(chan error, 1))
+			go func(o executehelpers.Output, outputChan chan<- error) {
 				if o.Path != "" {
-					executehelpers.Download(client, o)
+					err := executehelpers.Download(client, o, command.CleanOutputs, progress)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "failed to download output `%s`: %s\n", o.Name, err)
+						outputChan <- err
+					}
 				}
 
 				close(outputChan)
@@ -108,25 +187,474 @@ func (command *ExecuteCommand) Execute(args []string) error {
 		}
 	}
 
-	eventSource, err := client.BuildEvents(fmt.Sprintf("%d", build.ID))
+	finished := make(chan int, 1)
+	go func() {
+		exitCode := eventstream.Render(progress.Wrap(logs), eventSource)
+		eventSource.Close()
+
+		// the build is aborted when an input fails to upload, so the failed
+		// upload is reported instead of the abort
+		if err := <-inputChan; err != nil {
+			exitCode = inputsFailedExitCode
+		}
+
+		for _, outputChan := range outputChans {
+			if err := <-outputChan; err != nil && exitCode == 0 {
+				exitCode = outputsFailedExitCode
+			}
+		}
+
+		progress.Stop()
+
+		finished <- exitCode
+	}()
+
+	return build, finished, nil
+}
+
+type preparedBuild struct {
+	tasks      []executehelpers.Task
+	privileged bool
+	tags       []string
+
+	inputs  []executehelpers.Input
+	outputs []executehelpers.Output
+
+	// params given on the command line, which are masked when printed
+	secretParams []string
+}
+
+// prepare loads the task configs, either from the given files or from a task
+// step of the --inputs-from job, and determines the inputs and outputs.
+func (command *ExecuteCommand) prepare(client concourse.Client, args []string) (preparedBuild, error) {
+	var tasks []executehelpers.Task
+	var step atc.PlanConfig
+
+	// the inputs of a task whose config is loaded by the build
+	var remoteTaskInputs []atc.TaskInputConfig
+
+	if command.Attempts < 1 {
+		return preparedBuild{}, errors.New("--attempts must be at least 1")
+	}
+
+	if command.InputsFrom.JobName != "" && command.InputsFromBuild.BuildName != "" {
+		return preparedBuild{}, errors.New("--inputs-from and --inputs-from-build cannot be used together")
+	}
+
+	if command.Task != "" {
+		if len(command.TaskConfigs) > 0 {
+			return preparedBuild{}, errors.New("--task and --config cannot be used together")
+		}
+
+		job := command.InputsFrom
+		if command.InputsFromBuild.BuildName != "" {
+			job = command.InputsFromBuild.Job()
+		}
+
+		if job.JobName == "" {
+			return preparedBuild{}, errors.New("--task requires a job to be specified with --inputs-from")
+		}
+
+		var jobConfig atc.JobConfig
+		var err error
+		step, jobConfig, err = executehelpers.FindJobTask(client, job, command.Task)
+		if err != nil {
+			return preparedBuild{}, err
+		}
+
+		taskConfig, found, err := executehelpers.JobTaskConfig(step, command.Inputs)
+		if err != nil {
+			return preparedBuild{}, err
+		}
+
+		if found {
+			tasks = []executehelpers.Task{
+				{Name: "one-off", Config: config.OverrideTaskConfig(taskConfig, args)},
+			}
+		} else {
+			if len(args) > 0 {
+				return preparedBuild{}, fmt.Errorf("arguments can not be passed through to a task config loaded from `%s`", step.TaskConfigPath)
+			}
+
+			if command.Image != "" {
+				return preparedBuild{}, fmt.Errorf("--image can not be used with a task config loaded from `%s`", step.TaskConfigPath)
+			}
+
+			task, inputs, err := executehelpers.RemoteJobTask(jobConfig, step)
+			if err != nil {
+				return preparedBuild{}, err
+			}
+
+			tasks = []executehelpers.Task{task}
+			remoteTaskInputs = inputs
+		}
+	} else {
+		loaded, err := command.loadTasks(args)
+		if err != nil {
+			return preparedBuild{}, err
+		}
+
+		tasks = loaded
+	}
+
+	if command.Image != "" && command.ImageDir != "" {
+		return preparedBuild{}, errors.New("--image and --image-dir cannot be used together")
+	}
+
+	params, err := command.params()
+	if err != nil {
+		return preparedBuild{}, err
+	}
+
+	// only warn about params that no task declares
+	undeclaredCount := map[string]int{}
+
+	for i, task := range tasks {
+		if task.ConfigPath != "" {
+			// which params the loaded config declares is not known
+			task.Params = applyRemoteParams(task.Params, params)
+			tasks[i] = task
+			continue
+		}
+
+		if command.Image != "" {
+			task.Config = config.OverrideImage(task.Config, command.Image)
+		}
+
+		var undeclared []string
+		task.Config, undeclared = config.ApplyParams(task.Config, params)
+
+		for _, name := range undeclared {
+			undeclaredCount[name]++
+		}
+
+		tasks[i] = task
+	}
+
+	undeclared := []string{}
+	for name, count := range undeclaredCount {
+		if count == len(tasks) {
+			undeclared = append(undeclared, name)
+		}
+	}
+
+	if len(undeclared) > 0 {
+		sort.Strings(undeclared)
+
+		displayhelpers.PrintWarningHeader()
+
+		for _, name := range undeclared {
+			fmt.Fprintf(os.Stderr, "  - param `%s` is not declared by the task\n", name)
+		}
+	}
+
+	inputs, err := executehelpers.DetermineInputs(
+		client,
+		append(executehelpers.ExternalInputs(tasks), remoteTaskInputs...),
+		command.Inputs,
+		command.InputsFrom,
+		command.InputsFromBuild,
+		step.InputMapping,
+	)
+	if err != nil {
+		return preparedBuild{}, err
+	}
+
+	if command.ImageDir != "" {
+		image, err := executehelpers.ImageInput(client, string(command.ImageDir))
+		if err != nil {
+			return preparedBuild{}, err
+		}
+
+		inputs = append(inputs, image)
+	}
+
+	outputs, err := executehelpers.DetermineOutputs(
+		client,
+		executehelpers.AllOutputs(tasks),
+		command.Outputs,
+	)
+	if err != nil {
+		return preparedBuild{}, err
+	}
+
+	tags := command.Tags
+	if len(tags) == 0 {
+		tags = step.Tags
+	}
+
+	secretParams := []string{}
+	for name := range params {
+		secretParams = append(secretParams, name)
+	}
+
+	return preparedBuild{
+		tasks:        tasks,
+		privileged:   command.Privileged || step.Privileged,
+		tags:         tags,
+		inputs:       inputs,
+		outputs:      outputs,
+		secretParams: secretParams,
+	}, nil
+}
+
+// applyRemoteParams applies the given params on top of the step's params of a
+// task whose config is loaded by the build.
+func applyRemoteParams(stepParams atc.Params, params map[string]string) atc.Params {
+	if len(params) == 0 {
+		return stepParams
+	}
+
+	applied := atc.Params{}
+	for name, value := range stepParams {
+		applied[name] = value
+	}
+
+	for name, value := range params {
+		applied[name] = value
+	}
+
+	return applied
+}
+
+// loadTasks loads the task configs given with --config. A single task is
+// named one-off, while several are named after their files.
+func (command *ExecuteCommand) loadTasks(args []string) ([]executehelpers.Task, error) {
+	if len(command.TaskConfigs) == 0 {
+		return nil, errors.New("a task config must be specified with --config, or a job's task with --task")
+	}
+
+	if len(command.TaskConfigs) > 1 && len(args) > 0 {
+		return nil, errors.New("arguments can only be passed through to a single task")
+	}
+
+	paths := []string{}
+	for _, path := range command.TaskConfigs {
+		paths = append(paths, string(path))
+	}
+
+	names := []string{"one-off"}
+	if len(paths) > 1 {
+		names = executehelpers.TaskNames(paths)
+	}
+
+	tasks := []executehelpers.Task{}
+	for i, path := range paths {
+		taskConfig, err := config.LoadTaskConfig(path, args)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, executehelpers.Task{
+			Name:   names[i],
+			Config: taskConfig,
+		})
+	}
+
+	return tasks, nil
+}
+
+// deterministic archives would lose the ownership and modes that an image's
+// rootfs depends on
+func (command *ExecuteCommand) deterministic(input executehelpers.Input) bool {
+	return !command.NonDeterministic && !input.UseAsImage
+}
+
+// params loads the params given with --params-from and --param, the latter
+// taking precedence.
+func (command *ExecuteCommand) params() (map[string]string, error) {
+	params := map[string]string{}
+
+	for _, path := range command.ParamsFrom {
+		fileParams, err := config.LoadParamsFile(string(path))
+		if err != nil {
+			return nil, err
+		}
+
+		for name, value := range fileParams {
+			params[name] = value
+		}
+	}
+
+	for _, param := range command.Params {
+		params[param.Name] = param.Value
+	}
+
+	return params, nil
+}
+
+// dryRun resolves the inputs and outputs and prints the plan that would be
+// run, without creating any pipes or a build.
+func (command *ExecuteCommand) dryRun(client concourse.Client, args []string) error {
+	prepared, err := command.prepare(executehelpers.DryRunClient(client), args)
 	if err != nil {
 		return err
 	}
 
-	exitCode := eventstream.Render(os.Stdout, eventSource)
-	eventSource.Close()
+	err = executehelpers.InputMappingTable(prepared.inputs).Render(os.Stderr)
+	if err != nil {
+		return err
+	}
 
-	<-inputChan
+	plan, err := executehelpers.BuildPlan(
+		prepared.privileged,
+		prepared.inputs,
+		prepared.outputs,
+		prepared.tasks,
+		prepared.tags,
+		command.Attempts,
+		Fly.Target,
+	)
+	if err != nil {
+		return err
+	}
 
-	if len(outputs) > 0 {
-		for _, outputChan := range outputChans {
-			<-outputChan
+	redacted, err := executehelpers.RedactPlan(plan, prepared.secretParams)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	if command.JSON {
+		payload, err = json.MarshalIndent(redacted, "", "  ")
+		payload = append(payload, '\n')
+	} else {
+		payload, err = yaml.Marshal(redacted)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(payload)
+	return err
+}
+
+// watchFiles runs the build, and starts it over whenever the local inputs
+// change, aborting the build that is still running.
+func (command *ExecuteCommand) watchFiles(client concourse.Client, args []string) error {
+	paths, outputPaths, err := command.localPaths(client, args)
+	if err != nil {
+		return err
+	}
+
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(watchFilesInterval)
+	defer ticker.Stop()
+
+	exitCode := 0
+
+	for {
+		snapshot, err := executehelpers.SnapshotInputs(paths, outputPaths, command.ExcludeIgnored, command.Exclude, command.Include)
+		if err != nil {
+			return err
+		}
+
+		build, finished, err := command.start(client, args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		}
+
+		var timeout <-chan time.Time
+		if finished == nil {
+			fmt.Fprintln(os.Stderr, "waiting for changes...")
+		} else {
+			timeout = command.timeout()
+		}
+
+	waiting:
+		for {
+			select {
+			case <-terminate:
+				if finished != nil {
+					abortBuild(client, build)
+
+					select {
+					case exitCode = <-finished:
+					case <-terminate:
+						fmt.Fprintln(os.Stderr, "exiting immediately")
+						os.Exit(2)
+					}
+				}
+
+				os.Exit(exitCode)
+
+			case exitCode = <-finished:
+				finished = nil
+				timeout = nil
+				fmt.Fprintln(os.Stderr, "waiting for changes...")
+
+			case <-timeout:
+				command.abortTimedOut(client, build)
+				<-finished
+				exitCode = timedOutExitCode
+
+				finished = nil
+				timeout = nil
+				fmt.Fprintln(os.Stderr, "waiting for changes...")
+
+			case <-ticker.C:
+				current, err := executehelpers.SnapshotInputs(paths, outputPaths, command.ExcludeIgnored, command.Exclude, command.Include)
+				if err != nil {
+					return err
+				}
+
+				if !current.Equal(snapshot) {
+					fmt.Fprintln(os.Stderr, "inputs changed, starting over")
+
+					if finished != nil {
+						abortBuild(client, build)
+						exitCode = <-finished
+					}
+
+					break waiting
+				}
+			}
 		}
 	}
+}
 
-	os.Exit(exitCode)
+// timeout fires once the build has run for longer than --timeout, and never
+// if no timeout was given.
+func (command *ExecuteCommand) timeout() <-chan time.Time {
+	if command.Timeout <= 0 {
+		return nil
+	}
 
-	return nil
+	return time.After(command.Timeout)
+}
+
+func (command *ExecuteCommand) abortTimedOut(client concourse.Client, build atc.Build) {
+	fmt.Fprintf(os.Stderr, "\nbuild timed out after %s\n", command.Timeout)
+	abortBuild(client, build)
+}
+
+// localPaths resolves the inputs and outputs the same way as the build,
+// without creating any pipes, so that inputs mapped to sibling directories are
+// watched along with those given explicitly, and outputs fetched into an input
+// are not mistaken for changes to it.
+func (command *ExecuteCommand) localPaths(client concourse.Client, args []string) ([]string, []string, error) {
+	prepared, err := command.prepare(executehelpers.DryRunClient(client), args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inputPaths := []string{}
+	for _, input := range prepared.inputs {
+		if input.Path != "" {
+			inputPaths = append(inputPaths, input.Path)
+		}
+	}
+
+	outputPaths := []string{}
+	for _, output := range prepared.outputs {
+		if output.Path != "" {
+			outputPaths = append(outputPaths, output.Path)
+		}
+	}
+
+	return inputPaths, outputPaths, nil
 }
 
 func abortOnSignal(
@@ -136,11 +664,7 @@ func abortOnSignal(
 ) {
 	<-terminate
 
-	fmt.Fprintf(os.Stderr, "\naborting...\n")
-
-	err := client.AbortBuild(strconv.Itoa(build.ID))
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "failed to abort:", err)
+	if !abortBuild(client, build) {
 		return
 	}
 
@@ -149,3 +673,15 @@ func abortOnSignal(
 	fmt.Fprintln(os.Stderr, "exiting immediately")
 	os.Exit(2)
 }
+
+func abortBuild(client concourse.Client, build atc.Build) bool {
+	fmt.Fprintf(os.Stderr, "\naborting...\n")
+
+	err := client.AbortBuild(strconv.Itoa(build.ID))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to abort:", err)
+		return false
+	}
+
+	return true
+}
@@ -17,8 +17,9 @@ type FlyCommand struct {
 
 	Checklist ChecklistCommand `command:"checklist" alias:"cl" description:"Print a Checkfile of the given pipeline"`
 
-	Execute ExecuteCommand `command:"execute" alias:"e" description:"Execute a one-off build using local bits"`
-	Watch   WatchCommand   `command:"watch"   alias:"w" description:"Stream a build's output"`
+	Execute      ExecuteCommand      `command:"execute"       alias:"e"  description:"Execute a one-off build using local bits"`
+	ValidateTask ValidateTaskCommand `command:"validate-task" alias:"vt" description:"Validate a task config without contacting a target"`
+	Watch        WatchCommand        `command:"watch"         alias:"w"  description:"Stream a build's output"`
 
 	Containers ContainersCommand `command:"containers" alias:"cs" description:"Print the active containers"`
 	Hijack     HijackCommand     `command:"hijack"     alias:"intercept" alias:"i" description:"Execute a command in a container"`
@@ -30,12 +31,16 @@ type FlyCommand struct {
 	DestroyPipeline DestroyPipelineCommand `command:"destroy-pipeline" alias:"dp" description:"Destroy a pipeline"`
 	GetPipeline     GetPipelineCommand     `command:"get-pipeline"     alias:"gp" description:"Get a pipeline's current configuration"`
 	SetPipeline     SetPipelineCommand     `command:"set-pipeline"     alias:"sp" description:"Create or update a pipeline's configuration"`
+	Vars            VarsCommand            `command:"vars"                        description:"List the variables referenced by a pipeline configuration and where they come from"`
+	EncryptVars     EncryptVarsCommand     `command:"encrypt-vars"                description:"Encrypt a variables file for use with set-pipeline"`
+	DecryptVars     DecryptVarsCommand     `command:"decrypt-vars"                description:"Decrypt an encrypted variables file"`
 	PausePipeline   PausePipelineCommand   `command:"pause-pipeline"   alias:"pp" description:"Pause a pipeline"`
 	UnpausePipeline UnpausePipelineCommand `command:"unpause-pipeline" alias:"up" description:"Un-pause a pipeline"`
 	RenamePipeline  RenamePipelineCommand  `command:"rename-pipeline"  alias:"rp" description:"Rename a pipeline"`
 
 	Builds     BuildsCommand     `command:"builds"      alias:"bs" description:"List builds data"`
 	AbortBuild AbortBuildCommand `command:"abort-build" alias:"ab" description:"Abort a build"`
+	RerunBuild RerunBuildCommand `command:"rerun-build" alias:"rb" description:"Rerun a build with the same input versions"`
 
 	TriggerJob TriggerJobCommand `command:"trigger-job" alias:"tj" description:"Start a job in a pipeline"`
 
@@ -1,6 +1,7 @@
 package executehelpers
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/concourse/atc"
@@ -13,60 +14,57 @@ func CreateBuild(
 	privileged bool,
 	inputs []Input,
 	outputs []Output,
-	config atc.TaskConfig,
+	tasks []Task,
 	tags []string,
+	attempts int,
 	target rc.TargetName,
 ) (atc.Build, error) {
-	fact := atc.NewPlanFactory(time.Now().Unix())
-
-	if err := config.Validate(); err != nil {
-		return atc.Build{}, err
-	}
-
-	targetProps, err := rc.SelectTarget(target)
+	plan, err := BuildPlan(privileged, inputs, outputs, tasks, tags, attempts, target)
 	if err != nil {
 		return atc.Build{}, err
 	}
 
-	buildInputs := atc.AggregatePlan{}
-	for _, input := range inputs {
-		var getPlan atc.GetPlan
-		if input.Path != "" {
-			source := atc.Source{
-				"uri": input.Pipe.ReadURL,
-			}
+	return client.CreateBuild(plan)
+}
 
-			if auth, ok := targetAuthorization(targetProps.Token); ok {
-				source["authorization"] = auth
-			}
+func BuildPlan(
+	privileged bool,
+	inputs []Input,
+	outputs []Output,
+	tasks []Task,
+	tags []string,
+	attempts int,
+	target rc.TargetName,
+) (atc.Plan, error) {
+	fact := atc.NewPlanFactory(time.Now().Unix())
 
-			getPlan = atc.GetPlan{
-				Name:   input.Name,
-				Type:   "archive",
-				Source: source,
-			}
-		} else {
-			getPlan = atc.GetPlan{
-				Name:    input.Name,
-				Type:    input.BuildInput.Type,
-				Source:  input.BuildInput.Source,
-				Version: input.BuildInput.Version,
-				Params:  input.BuildInput.Params,
-				Tags:    input.BuildInput.Tags,
+	for _, task := range tasks {
+		if task.ConfigPath != "" {
+			continue
+		}
+
+		if err := task.Config.Validate(); err != nil {
+			if len(tasks) > 1 {
+				return atc.Plan{}, fmt.Errorf("invalid task `%s`: %s", task.Name, err)
 			}
+
+			return atc.Plan{}, err
 		}
+	}
 
-		buildInputs = append(buildInputs, fact.NewPlan(getPlan))
+	targetProps, err := rc.SelectTarget(target)
+	if err != nil {
+		return atc.Plan{}, err
 	}
 
-	taskPlan := fact.NewPlan(atc.TaskPlan{
-		Name:       "one-off",
-		Privileged: privileged,
-		Config:     &config,
-	})
+	buildInputs := inputGetPlans(fact, inputs, targetProps)
 
-	if len(tags) != 0 {
-		taskPlan.Task.Tags = tags
+	steps := atc.DoPlan{
+		fact.NewPlan(buildInputs),
+	}
+
+	for _, task := range tasks {
+		steps = append(steps, taskPlan(fact, task, privileged, inputs, tags, attempts))
 	}
 
 	buildOutputs := atc.AggregatePlan{}
@@ -93,21 +91,91 @@ func CreateBuild(
 
 	var plan atc.Plan
 	if len(buildOutputs) == 0 {
-		plan = fact.NewPlan(atc.DoPlan{
-			fact.NewPlan(buildInputs),
-			taskPlan,
-		})
+		plan = fact.NewPlan(steps)
 	} else {
 		plan = fact.NewPlan(atc.EnsurePlan{
-			Step: fact.NewPlan(atc.DoPlan{
-				fact.NewPlan(buildInputs),
-				taskPlan,
-			}),
+			Step: fact.NewPlan(steps),
 			Next: fact.NewPlan(buildOutputs),
 		})
 	}
 
-	return client.CreateBuild(plan)
+	return plan, nil
+}
+
+func taskPlan(fact atc.PlanFactory, task Task, privileged bool, inputs []Input, tags []string, attempts int) atc.Plan {
+	newTaskPlan := func() atc.Plan {
+		plan := fact.NewPlan(atc.TaskPlan{
+			Name:       task.Name,
+			Privileged: privileged,
+		})
+
+		if task.ConfigPath != "" {
+			plan.Task.ConfigPath = task.ConfigPath
+			plan.Task.Params = task.Params
+		} else {
+			config := task.Config
+			plan.Task.Config = &config
+		}
+
+		if len(tags) != 0 {
+			plan.Task.Tags = tags
+		}
+
+		for _, input := range inputs {
+			if input.UseAsImage {
+				plan.Task.ImageArtifactName = input.Name
+			}
+		}
+
+		return plan
+	}
+
+	if attempts <= 1 {
+		return newTaskPlan()
+	}
+
+	retry := atc.RetryPlan{}
+	for i := 0; i < attempts; i++ {
+		retry = append(retry, newTaskPlan())
+	}
+
+	return fact.NewPlan(retry)
+}
+
+func inputGetPlans(fact atc.PlanFactory, inputs []Input, targetProps rc.TargetProps) atc.AggregatePlan {
+	buildInputs := atc.AggregatePlan{}
+	for _, input := range inputs {
+		var getPlan atc.GetPlan
+		if input.Path != "" {
+			source := atc.Source{
+				"uri": input.Pipe.ReadURL,
+			}
+
+			if auth, ok := targetAuthorization(targetProps.Token); ok {
+				source["authorization"] = auth
+			}
+
+			getPlan = atc.GetPlan{
+				Name:   input.Name,
+				Type:   "archive",
+				Source: source,
+			}
+		} else {
+			getPlan = atc.GetPlan{
+				Name:          input.Name,
+				Type:          input.BuildInput.Type,
+				Source:        input.BuildInput.Source,
+				Version:       input.BuildInput.Version,
+				Params:        input.BuildInput.Params,
+				Tags:          input.BuildInput.Tags,
+				ResourceTypes: input.ResourceTypes,
+			}
+		}
+
+		buildInputs = append(buildInputs, fact.NewPlan(getPlan))
+	}
+
+	return buildInputs
 }
 
 func targetAuthorization(token *rc.TargetToken) (string, bool) {
@@ -0,0 +1,184 @@
+package executehelpers
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// deterministicTarStreamFrom archives the paths such that the same files
+// always produce the same archive: entries are sorted, ownership and
+// timestamps are dropped, and hard links are stored as the files themselves.
+// Permissions are kept as they are.
+func deterministicTarStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
+	return deterministicTarStreamWith(tarEntriesStreamFrom, workDir, paths)
+}
+
+// deterministicTarStreamWith archives the sorted entries with the given tar,
+// normalizing its output so that any tar gives the same result.
+func deterministicTarStreamWith(
+	archiveEntries func(workDir string, entries []string) (io.ReadCloser, error),
+	workDir string,
+	paths []string,
+) (io.ReadCloser, error) {
+	absWorkDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := sortedTarEntries(absWorkDir, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := archiveEntries(absWorkDir, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	r, w := io.Pipe()
+
+	go func() {
+		defer archive.Close()
+
+		w.CloseWithError(normalizeTar(absWorkDir, archive, w))
+	}()
+
+	return r, nil
+}
+
+func sortedTarEntries(workDir string, paths []string) ([]string, error) {
+	seen := map[string]bool{}
+	entries := []string{}
+
+	for _, p := range paths {
+		err := filepath.Walk(filepath.Join(workDir, p), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relative, err := filepath.Rel(workDir, path)
+			if err != nil {
+				return err
+			}
+
+			if !seen[relative] {
+				seen[relative] = true
+				entries = append(entries, relative)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Sort(byTarPath(entries))
+
+	return entries, nil
+}
+
+type byTarPath []string
+
+func (ps byTarPath) Len() int          { return len(ps) }
+func (ps byTarPath) Swap(i int, j int) { ps[i], ps[j] = ps[j], ps[i] }
+func (ps byTarPath) Less(i int, j int) bool {
+	// the root directory goes first, as the shell tar would have it
+	if ps[i] == "." || ps[j] == "." {
+		return ps[i] == "." && ps[j] != "."
+	}
+
+	return filepath.ToSlash(ps[i]) < filepath.ToSlash(ps[j])
+}
+
+func normalizeTar(workDir string, src io.Reader, dst io.Writer) error {
+	tr := tar.NewReader(src)
+	tw := tar.NewWriter(dst)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		normalized := normalizeTarHeader(hdr)
+
+		if hdr.Typeflag == tar.TypeLink {
+			// which of the paths is the link depends on the order the tar
+			// found them in
+			err = writeLinkedTarFile(tw, normalized, filepath.Join(workDir, filepath.FromSlash(hdr.Name)))
+		} else {
+			err = tw.WriteHeader(normalized)
+			if err == nil {
+				_, err = io.Copy(tw, tr)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// normalizeTarHeader keeps only what describes an entry's contents, dropping
+// ownership, timestamps and anything specific to the tar which wrote it.
+func normalizeTarHeader(hdr *tar.Header) *tar.Header {
+	normalized := &tar.Header{
+		Typeflag: hdr.Typeflag,
+		Name:     path.Clean(hdr.Name),
+		Linkname: filepath.ToSlash(hdr.Linkname),
+		Size:     hdr.Size,
+		Mode:     hdr.Mode & 07777,
+		ModTime:  time.Unix(0, 0),
+		Devmajor: hdr.Devmajor,
+		Devminor: hdr.Devminor,
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeRegA, tar.TypeGNUSparse:
+		normalized.Typeflag = tar.TypeReg
+
+	case tar.TypeDir:
+		normalized.Name = strings.TrimSuffix(normalized.Name, "/") + "/"
+	}
+
+	return normalized
+}
+
+func writeLinkedTarFile(tw *tar.Writer, hdr *tar.Header, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr.Typeflag = tar.TypeReg
+	hdr.Linkname = ""
+	hdr.Size = info.Size()
+
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, file)
+	return err
+}
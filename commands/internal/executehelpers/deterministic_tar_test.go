@@ -0,0 +1,142 @@
+package executehelpers_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeterministicTarStreamFrom", func() {
+	var workDir string
+
+	BeforeEach(func() {
+		var err error
+		workDir, err = ioutil.TempDir("", "deterministic-tar")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(workDir, "b", "c"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(workDir, "b", "c", "file"), []byte("some-content"), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(workDir, "a-script"), []byte("#!/bin/sh"), 0700)).To(Succeed())
+		Expect(os.Symlink("b/c/file", filepath.Join(workDir, "link"))).To(Succeed())
+
+		Expect(os.Chmod(workDir, 0755)).To(Succeed())
+		Expect(os.Chmod(filepath.Join(workDir, "b"), 0700)).To(Succeed())
+		Expect(os.Chmod(filepath.Join(workDir, "b", "c"), 0750)).To(Succeed())
+		Expect(os.Chmod(filepath.Join(workDir, "a-script"), os.ModeSetuid|0755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(workDir)
+	})
+
+	archiveWith := func(tarStreamFrom func(string, []string) (io.ReadCloser, error), paths []string) []byte {
+		stream, err := tarStreamFrom(workDir, paths)
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		contents, err := ioutil.ReadAll(stream)
+		Expect(err).NotTo(HaveOccurred())
+
+		return contents
+	}
+
+	archive := func(paths []string) []byte {
+		return archiveWith(DeterministicTarStreamFrom, paths)
+	}
+
+	headers := func(contents []byte) []*tar.Header {
+		tr := tar.NewReader(bytes.NewReader(contents))
+
+		hdrs := []*tar.Header{}
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				break
+			}
+
+			hdrs = append(hdrs, hdr)
+		}
+
+		return hdrs
+	}
+
+	It("produces the same archive regardless of timestamps", func() {
+		first := archive([]string{"."})
+
+		later := time.Now().Add(time.Hour)
+		Expect(os.Chtimes(filepath.Join(workDir, "b", "c", "file"), later, later)).To(Succeed())
+
+		Expect(archive([]string{"."})).To(Equal(first))
+	})
+
+	It("sorts the entries, whatever order the paths are given in", func() {
+		hdrs := headers(archive([]string{"link", "b/c/file", "a-script"}))
+
+		names := []string{}
+		for _, hdr := range hdrs {
+			names = append(names, hdr.Name)
+		}
+
+		Expect(names).To(Equal([]string{"a-script", "b/c/file", "link"}))
+	})
+
+	It("normalizes ownership and timestamps, keeping permissions", func() {
+		hdrs := headers(archive([]string{"."}))
+
+		modes := map[string]int64{}
+		for _, hdr := range hdrs {
+			Expect(hdr.Uid).To(BeZero())
+			Expect(hdr.Gid).To(BeZero())
+			Expect(hdr.Uname).To(BeEmpty())
+			Expect(hdr.Gname).To(BeEmpty())
+			Expect(hdr.ModTime.Unix()).To(BeZero())
+
+			if hdr.Typeflag != tar.TypeSymlink {
+				modes[hdr.Name] = hdr.Mode
+			}
+		}
+
+		Expect(modes).To(Equal(map[string]int64{
+			"./":       0755,
+			"a-script": 04755,
+			"b/":       0700,
+			"b/c/":     0750,
+			"b/c/file": 0600,
+		}))
+	})
+
+	It("stores hard links as the files themselves", func() {
+		Expect(os.Link(filepath.Join(workDir, "b", "c", "file"), filepath.Join(workDir, "hard-link"))).To(Succeed())
+
+		for _, hdr := range headers(archive([]string{"."})) {
+			if hdr.Name == "b/c/file" || hdr.Name == "hard-link" {
+				Expect(hdr.Typeflag).To(Equal(byte(tar.TypeReg)))
+				Expect(hdr.Size).To(Equal(int64(len("some-content"))))
+			}
+		}
+	})
+
+	Context("when the shell tar is available", func() {
+		BeforeEach(func() {
+			if _, err := exec.LookPath("tar"); err != nil {
+				Skip("tar is not available")
+			}
+
+			Expect(os.Link(filepath.Join(workDir, "b", "c", "file"), filepath.Join(workDir, "hard-link"))).To(Succeed())
+		})
+
+		It("produces the same archive as without it", func() {
+			Expect(archive([]string{"."})).To(Equal(archiveWith(NativeDeterministicTarStreamFrom, []string{"."})))
+		})
+	})
+})
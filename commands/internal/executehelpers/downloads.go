@@ -1,36 +1,136 @@
 package executehelpers
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/concourse/go-concourse/concourse"
 )
 
-func Download(client concourse.Client, output Output) {
+// StdoutPath is given as an output's path to stream it to stdout as a tar.
+const StdoutPath = "-"
+
+func Download(client concourse.Client, output Output, clean bool, progress *ProgressRenderer) (err error) {
 	path := output.Path
 	pipe := output.Pipe
 
 	response, err := client.HTTPClient().Get(pipe.ReadURL)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "download request failed:", err)
+		return fmt.Errorf("download request failed: %s", err)
 	}
 
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		fmt.Fprintln(os.Stderr, badResponseError("downloading bits", response))
-		panic("unexpected-response-code")
+		return badResponseError("downloading bits", response)
+	}
+
+	// the length is -1 when unknown, e.g. when the output is streamed
+	total := response.ContentLength
+	if total < 0 {
+		total = 0
+	}
+
+	transfer := progress.Track("downloading", output.Name, total)
+	defer func() { transfer.Done(err) }()
+
+	stream := transfer.Reader(response.Body)
+
+	switch {
+	case path == StdoutPath:
+		return writeTar(os.Stdout, stream)
+
+	case isArchivePath(path, ".tgz", ".tar.gz"):
+		return writeFile(path, func(file io.Writer) error {
+			_, err := io.Copy(file, stream)
+			return err
+		})
+
+	case isArchivePath(path, ".tar"):
+		return writeFile(path, func(file io.Writer) error {
+			return writeTar(file, stream)
+		})
+	}
+
+	if clean {
+		err = cleanDir(path)
+		if err != nil {
+			return err
+		}
 	}
 
 	err = os.MkdirAll(path, 0755)
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	return tarStreamTo(path, stream)
+}
+
+func isArchivePath(path string, extensions ...string) bool {
+	for _, extension := range extensions {
+		if strings.HasSuffix(path, extension) {
+			return true
+		}
 	}
 
-	err = tarStreamTo(path, response.Body)
+	return false
+}
+
+func writeTar(dest io.Writer, stream io.Reader) error {
+	gr, err := gzip.NewReader(stream)
 	if err != nil {
-		panic(err)
+		return err
 	}
+
+	_, err = io.Copy(dest, gr)
+	return err
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// cleanDir removes everything in the directory but leaves the directory
+// itself, which may well be the working directory.
+func cleanDir(path string) error {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, entry := range entries {
+		err := os.RemoveAll(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,104 @@
+package executehelpers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+const redactedValue = "((redacted))"
+
+var ErrDryRun = errors.New("refusing to create a build during a dry run")
+
+// DryRunClient behaves like the given client for anything read-only, but
+// hands out placeholder pipes and refuses to create builds.
+func DryRunClient(client concourse.Client) concourse.Client {
+	return dryRunClient{Client: client}
+}
+
+type dryRunClient struct {
+	concourse.Client
+}
+
+func (client dryRunClient) CreatePipe() (atc.Pipe, error) {
+	url := client.URL() + "/api/v1/pipes/(dry-run)"
+
+	return atc.Pipe{
+		ID:       "(dry-run)",
+		ReadURL:  url,
+		WriteURL: url,
+	}, nil
+}
+
+func (client dryRunClient) CreateBuild(atc.Plan) (atc.Build, error) {
+	return atc.Build{}, ErrDryRun
+}
+
+// RedactPlan converts the plan to a generic structure suitable for printing,
+// with any authorization values and the given task params masked.
+func RedactPlan(plan atc.Plan, secretParams []string) (interface{}, error) {
+	payload, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	var redacted interface{}
+	err = json.Unmarshal(payload, &redacted)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]bool{}
+	for _, name := range secretParams {
+		secrets[name] = true
+	}
+
+	return redact(redacted, secrets), nil
+}
+
+func redact(value interface{}, secretParams map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if key == "authorization" {
+				v[key] = redactedValue
+			} else {
+				v[key] = redact(val, secretParams)
+			}
+		}
+
+		if task, ok := v["task"].(map[string]interface{}); ok {
+			redactTaskParams(task, secretParams)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redact(val, secretParams)
+		}
+	}
+
+	return value
+}
+
+// redactTaskParams redacts the params of the task's config, or those applied
+// on top of the config it loads from a file.
+func redactTaskParams(task map[string]interface{}, secretParams map[string]bool) {
+	paramSets := []interface{}{task["params"]}
+	if config, ok := task["config"].(map[string]interface{}); ok {
+		paramSets = append(paramSets, config["params"])
+	}
+
+	for _, paramSet := range paramSets {
+		params, ok := paramSet.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for key := range params {
+			if secretParams[key] {
+				params[key] = redactedValue
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package executehelpers_test
+
+import (
+	"github.com/concourse/atc"
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RedactPlan", func() {
+	It("masks authorization values in sources", func() {
+		fact := atc.NewPlanFactory(0)
+
+		plan := fact.NewPlan(atc.GetPlan{
+			Name: "some-input",
+			Type: "archive",
+			Source: atc.Source{
+				"uri":           "https://example.com/api/v1/pipes/some-pipe",
+				"authorization": "Bearer some-token",
+			},
+		})
+
+		redacted, err := RedactPlan(plan, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		source := redacted.(map[string]interface{})["get"].(map[string]interface{})["source"]
+		Expect(source).To(Equal(map[string]interface{}{
+			"uri":           "https://example.com/api/v1/pipes/some-pipe",
+			"authorization": "((redacted))",
+		}))
+	})
+
+	It("masks the given task params", func() {
+		fact := atc.NewPlanFactory(0)
+
+		plan := fact.NewPlan(atc.TaskPlan{
+			Name: "one-off",
+			Config: &atc.TaskConfig{
+				Params: map[string]string{
+					"SECRET": "some-secret",
+					"PUBLIC": "some-value",
+				},
+			},
+		})
+
+		redacted, err := RedactPlan(plan, []string{"SECRET"})
+		Expect(err).NotTo(HaveOccurred())
+
+		config := redacted.(map[string]interface{})["task"].(map[string]interface{})["config"]
+		Expect(config.(map[string]interface{})["params"]).To(Equal(map[string]interface{}{
+			"SECRET": "((redacted))",
+			"PUBLIC": "some-value",
+		}))
+	})
+})
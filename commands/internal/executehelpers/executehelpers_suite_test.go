@@ -0,0 +1,13 @@
+package executehelpers_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestExecutehelpers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Executehelpers Suite")
+}
@@ -0,0 +1,18 @@
+package executehelpers
+
+import (
+	"io"
+	"time"
+)
+
+func NewTerminalProgressRenderer(dst io.Writer, interval time.Duration) *ProgressRenderer {
+	return newProgressRenderer(dst, true, interval)
+}
+
+var DeterministicTarStreamFrom = deterministicTarStreamFrom
+
+// NativeDeterministicTarStreamFrom archives like DeterministicTarStreamFrom,
+// but never with the shell tar.
+func NativeDeterministicTarStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
+	return deterministicTarStreamWith(nativeTarEntriesStreamFrom, workDir, paths)
+}
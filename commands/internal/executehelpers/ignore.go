@@ -0,0 +1,155 @@
+package executehelpers
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const ignoreFileName = ".flyignore"
+
+type IgnoreRules []ignoreRule
+
+type ignoreRule struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// LoadIgnoreRules reads the .flyignore file in dir, if there is one, and
+// appends the given exclude and include patterns. Includes are applied last so
+// that they take precedence over everything else.
+func LoadIgnoreRules(dir string, excludes []string, includes []string) (IgnoreRules, error) {
+	rules := IgnoreRules{}
+
+	file, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if err == nil {
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+				rules = append(rules, rule)
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, pattern := range excludes {
+		if rule, ok := parseIgnoreRule(pattern); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	for _, pattern := range includes {
+		if rule, ok := parseIgnoreRule(pattern); ok {
+			rule.negate = true
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// patterns without a slash match at any depth; everything else is
+	// relative to the input directory
+	if !strings.Contains(line, "/") {
+		rule.segments = []string{"**", line}
+	} else {
+		rule.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	}
+
+	return rule, true
+}
+
+// Excluded determines whether the given path, relative to the input
+// directory, should be left out of the upload. A path inherits the state of
+// its parent directory unless a rule matches the path itself.
+func (rules IgnoreRules) Excluded(relPath string, isDir bool) bool {
+	segments := strings.Split(filepath.ToSlash(relPath), "/")
+
+	excluded := false
+	for i := range segments {
+		dir := isDir || i < len(segments)-1
+
+		for _, rule := range rules {
+			if rule.dirOnly && !dir {
+				continue
+			}
+
+			if matchSegments(rule.segments, segments[:i+1]) {
+				excluded = !rule.negate
+			}
+		}
+	}
+
+	return excluded
+}
+
+func (rules IgnoreRules) hasNegations() bool {
+	for _, rule := range rules {
+		if rule.negate {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(pattern[0], segments[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,110 @@
+package executehelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IgnoreRules", func() {
+	var dir string
+	var excludes []string
+	var includes []string
+
+	var rules IgnoreRules
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-ignore")
+		Expect(err).NotTo(HaveOccurred())
+
+		excludes = nil
+		includes = nil
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		rules, err = LoadIgnoreRules(dir, excludes, includes)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("when there is no .flyignore and no patterns", func() {
+		It("excludes nothing", func() {
+			Expect(rules).To(BeEmpty())
+			Expect(rules.Excluded("some/file", false)).To(BeFalse())
+		})
+	})
+
+	Context("when there is a .flyignore", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(dir, ".flyignore"), []byte(`
+# build artifacts
+*.log
+build/
+/vendor
+docs/**/*.pdf
+!important.log
+`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("matches patterns without a slash at any depth", func() {
+			Expect(rules.Excluded("debug.log", false)).To(BeTrue())
+			Expect(rules.Excluded("some/nested/debug.log", false)).To(BeTrue())
+			Expect(rules.Excluded("debug.txt", false)).To(BeFalse())
+		})
+
+		It("matches directory-only patterns against directories and their contents", func() {
+			Expect(rules.Excluded("build", true)).To(BeTrue())
+			Expect(rules.Excluded("src/build/output.bin", false)).To(BeTrue())
+			Expect(rules.Excluded("build", false)).To(BeFalse())
+		})
+
+		It("anchors patterns with a leading slash to the input directory", func() {
+			Expect(rules.Excluded("vendor", true)).To(BeTrue())
+			Expect(rules.Excluded("vendor/lib/file.go", false)).To(BeTrue())
+			Expect(rules.Excluded("src/vendor", true)).To(BeFalse())
+		})
+
+		It("supports ** for any number of directories", func() {
+			Expect(rules.Excluded("docs/manual.pdf", false)).To(BeTrue())
+			Expect(rules.Excluded("docs/a/b/manual.pdf", false)).To(BeTrue())
+			Expect(rules.Excluded("other/manual.pdf", false)).To(BeFalse())
+		})
+
+		It("re-includes negated patterns", func() {
+			Expect(rules.Excluded("important.log", false)).To(BeFalse())
+		})
+
+		Context("when exclude patterns are given", func() {
+			BeforeEach(func() {
+				excludes = []string{"*.tmp"}
+			})
+
+			It("excludes them as well", func() {
+				Expect(rules.Excluded("scratch.tmp", false)).To(BeTrue())
+				Expect(rules.Excluded("debug.log", false)).To(BeTrue())
+			})
+		})
+
+		Context("when include patterns are given", func() {
+			BeforeEach(func() {
+				includes = []string{"build/keep.txt"}
+			})
+
+			It("includes them even inside excluded directories", func() {
+				Expect(rules.Excluded("build/keep.txt", false)).To(BeFalse())
+				Expect(rules.Excluded("build/other.txt", false)).To(BeTrue())
+			})
+		})
+	})
+})
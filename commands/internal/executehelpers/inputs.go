@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/ui"
 	"github.com/concourse/go-concourse/concourse"
+	"github.com/fatih/color"
 )
 
 type Input struct {
@@ -18,6 +22,57 @@ type Input struct {
 	Pipe atc.Pipe
 
 	BuildInput atc.BuildInput
+
+	// ResourceTypes are the pipeline's resource types, for fetching a build
+	// input of a custom type
+	ResourceTypes atc.ResourceTypes
+
+	// UseAsImage makes the input the task's image rather than one of its
+	// inputs
+	UseAsImage bool
+}
+
+const ImageInputName = "fly-image"
+
+// ImageInput uploads the local image directory to be used as the task's
+// image. Like the output of an image resource, it must contain the image's
+// filesystem in rootfs/ along with its metadata.json.
+func ImageInput(client concourse.Client, path string) (Input, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Input{}, err
+	}
+
+	err = validateImageDir(absPath)
+	if err != nil {
+		return Input{}, err
+	}
+
+	pipe, err := client.CreatePipe()
+	if err != nil {
+		return Input{}, err
+	}
+
+	return Input{
+		Name:       ImageInputName,
+		Path:       absPath,
+		Pipe:       pipe,
+		UseAsImage: true,
+	}, nil
+}
+
+func validateImageDir(path string) error {
+	info, err := os.Stat(filepath.Join(path, "rootfs"))
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("image directory `%s` must contain the image's filesystem in rootfs/", path)
+	}
+
+	info, err = os.Stat(filepath.Join(path, "metadata.json"))
+	if err != nil || info.IsDir() {
+		return fmt.Errorf("image directory `%s` must contain a metadata.json, e.g. {\"env\":[],\"user\":\"\"}", path)
+	}
+
+	return nil
 }
 
 func DetermineInputs(
@@ -25,13 +80,15 @@ func DetermineInputs(
 	taskInputs []atc.TaskInputConfig,
 	inputMappings []flaghelpers.InputPairFlag,
 	inputsFrom flaghelpers.JobFlag,
+	inputsFromBuild flaghelpers.JobBuildFlag,
+	jobInputMapping map[string]string,
 ) ([]Input, error) {
 	err := CheckForUnknownInputMappings(inputMappings, taskInputs)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(inputMappings) == 0 && inputsFrom.PipelineName == "" && inputsFrom.JobName == "" {
+	if len(inputMappings) == 0 && inputsFrom.PipelineName == "" && inputsFrom.JobName == "" && inputsFromBuild.BuildName == "" {
 		wd, err := os.Getwd()
 		if err != nil {
 			return nil, err
@@ -48,7 +105,12 @@ func DetermineInputs(
 		return nil, err
 	}
 
-	inputsFromJob, err := FetchInputsFromJob(client, inputsFrom)
+	var inputsFromJob map[string]Input
+	if inputsFromBuild.BuildName != "" {
+		inputsFromJob, err = FetchInputsFromJobBuild(client, inputsFromBuild)
+	} else {
+		inputsFromJob, err = FetchInputsFromJob(client, inputsFrom)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -57,9 +119,23 @@ func DetermineInputs(
 	for _, taskInput := range taskInputs {
 		input, found := inputsFromLocal[taskInput.Name]
 		if !found {
-			input, found = inputsFromJob[taskInput.Name]
-			if !found {
-				return nil, fmt.Errorf("missing required input `%s`", taskInput.Name)
+			artifactName := taskInput.Name
+			if mapped, ok := jobInputMapping[taskInput.Name]; ok {
+				artifactName = mapped
+			}
+
+			input, found = inputsFromJob[artifactName]
+			if found {
+				input.Name = taskInput.Name
+			} else {
+				input, found, err = siblingInput(client, taskInput.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				if !found {
+					return nil, fmt.Errorf("missing required input `%s`", taskInput.Name)
+				}
 			}
 		}
 
@@ -69,6 +145,83 @@ func DetermineInputs(
 	return inputs, nil
 }
 
+// siblingInput maps the input to a directory of the same name next to the
+// working directory, e.g. ../my-repo for the input my-repo.
+func siblingInput(client concourse.Client, name string) (Input, bool, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return Input{}, false, err
+	}
+
+	path := filepath.Join(filepath.Dir(wd), name)
+	if path == wd {
+		// the working directory is only an input when given with -i, or when
+		// no inputs are given at all
+		return Input{}, false, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return Input{}, false, nil
+	}
+
+	pipe, err := client.CreatePipe()
+	if err != nil {
+		return Input{}, false, err
+	}
+
+	return Input{
+		Name: name,
+		Path: path,
+		Pipe: pipe,
+	}, true, nil
+}
+
+// InputMappingTable shows where each of the inputs comes from.
+func InputMappingTable(inputs []Input) ui.Table {
+	table := ui.Table{
+		Headers: ui.TableRow{
+			{Contents: "input", Color: color.New(color.Bold)},
+			{Contents: "source", Color: color.New(color.Bold)},
+		},
+	}
+
+	for _, input := range inputs {
+		var source string
+		switch {
+		case input.UseAsImage:
+			source = "image " + input.Path
+		case input.Path != "":
+			source = input.Path
+		default:
+			source = fmt.Sprintf("resource %s %s", input.BuildInput.Resource, formatVersion(input.BuildInput.Version))
+		}
+
+		table.Data = append(table.Data, ui.TableRow{
+			{Contents: input.Name},
+			{Contents: source},
+		})
+	}
+
+	return table
+}
+
+func formatVersion(version atc.Version) string {
+	keys := []string{}
+	for key := range version {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s:%v", key, version[key]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
 func CheckForUnknownInputMappings(inputMappings []flaghelpers.InputPairFlag, validInputs []atc.TaskInputConfig) error {
 	for _, inputMapping := range inputMappings {
 		if !TaskInputsContainsName(validInputs, inputMapping.Name) {
@@ -133,3 +286,40 @@ func FetchInputsFromJob(client concourse.Client, inputsFrom flaghelpers.JobFlag)
 
 	return kvMap, nil
 }
+
+func FetchInputsFromJobBuild(client concourse.Client, inputsFrom flaghelpers.JobBuildFlag) (map[string]Input, error) {
+	build, found, err := client.JobBuild(inputsFrom.PipelineName, inputsFrom.JobName, inputsFrom.BuildName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errors.New("build not found")
+	}
+
+	config, _, _, found, err := client.PipelineConfig(inputsFrom.PipelineName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errors.New("pipeline not found")
+	}
+
+	jobConfig, found := config.Jobs.Lookup(inputsFrom.JobName)
+	if !found {
+		return nil, errors.New("job not found")
+	}
+
+	buildInputs, err := FetchInputsFromBuild(client, config, jobConfig, build)
+	if err != nil {
+		return nil, err
+	}
+
+	kvMap := map[string]Input{}
+	for _, input := range buildInputs {
+		kvMap[input.Name] = input
+	}
+
+	return kvMap, nil
+}
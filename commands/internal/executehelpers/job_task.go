@@ -0,0 +1,210 @@
+package executehelpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+func FindJobTask(client concourse.Client, job flaghelpers.JobFlag, taskName string) (atc.PlanConfig, atc.JobConfig, error) {
+	config, _, _, found, err := client.PipelineConfig(job.PipelineName)
+	if err != nil {
+		return atc.PlanConfig{}, atc.JobConfig{}, err
+	}
+
+	if !found {
+		return atc.PlanConfig{}, atc.JobConfig{}, errors.New("pipeline not found")
+	}
+
+	jobConfig, found := config.Jobs.Lookup(job.JobName)
+	if !found {
+		return atc.PlanConfig{}, atc.JobConfig{}, errors.New("job not found")
+	}
+
+	step, found := findTaskStep(jobConfig.Plan, taskName)
+	if !found {
+		return atc.PlanConfig{}, atc.JobConfig{}, fmt.Errorf("job `%s` has no task `%s`", job.JobName, taskName)
+	}
+
+	return step, jobConfig, nil
+}
+
+func findTaskStep(plan atc.PlanSequence, taskName string) (atc.PlanConfig, bool) {
+	return findStep(plan, func(step atc.PlanConfig) bool {
+		return step.Task == taskName
+	})
+}
+
+// findStep searches the plan for a step, including those nested in other
+// steps and their hooks.
+func findStep(plan atc.PlanSequence, matches func(atc.PlanConfig) bool) (atc.PlanConfig, bool) {
+	for _, step := range plan {
+		if matches(step) {
+			return step, true
+		}
+
+		nested := atc.PlanSequence{}
+		if step.Do != nil {
+			nested = append(nested, *step.Do...)
+		}
+
+		if step.Aggregate != nil {
+			nested = append(nested, *step.Aggregate...)
+		}
+
+		for _, hook := range []*atc.PlanConfig{step.Try, step.Success, step.Failure, step.Ensure} {
+			if hook != nil {
+				nested = append(nested, *hook)
+			}
+		}
+
+		if found, ok := findStep(nested, matches); ok {
+			return found, true
+		}
+	}
+
+	return atc.PlanConfig{}, false
+}
+
+// JobTaskConfig determines the config of a job's task step, either from the
+// step itself or from its file when the input the file is in is provided
+// locally. If it is not, the config is not found, and must be loaded by the
+// build instead; see RemoteJobTask. The step's params are applied on top.
+func JobTaskConfig(step atc.PlanConfig, inputMappings []flaghelpers.InputPairFlag) (atc.TaskConfig, bool, error) {
+	var config atc.TaskConfig
+
+	if step.TaskConfig != nil {
+		config = *step.TaskConfig
+	} else if step.TaskConfigPath != "" {
+		inputName, path, err := jobTaskConfigFile(step)
+		if err != nil {
+			return atc.TaskConfig{}, false, err
+		}
+
+		var inputPath string
+		for _, mapping := range inputMappings {
+			if mapping.Name == inputName {
+				inputPath = mapping.Path
+			}
+		}
+
+		if inputPath == "" {
+			return atc.TaskConfig{}, false, nil
+		}
+
+		configFile, err := ioutil.ReadFile(filepath.Join(inputPath, filepath.FromSlash(path)))
+		if err != nil {
+			return atc.TaskConfig{}, false, fmt.Errorf("failed to read task config: %s", err)
+		}
+
+		config, err = atc.LoadTaskConfig(configFile)
+		if err != nil {
+			return atc.TaskConfig{}, false, err
+		}
+	} else {
+		return atc.TaskConfig{}, false, fmt.Errorf("task `%s` has no config", step.Task)
+	}
+
+	config, err := applyStepParams(config, step.Params)
+	if err != nil {
+		return atc.TaskConfig{}, false, err
+	}
+
+	return config, true, nil
+}
+
+// RemoteJobTask runs a job's task step with its config loaded by the build
+// from the file in the job's input. As the config is not known up front, the
+// task is given all of the job's inputs, named as the step maps them.
+func RemoteJobTask(job atc.JobConfig, step atc.PlanConfig) (Task, []atc.TaskInputConfig, error) {
+	inputName, path, err := jobTaskConfigFile(step)
+	if err != nil {
+		return Task{}, nil, err
+	}
+
+	taskNames := map[string]string{}
+	for name, artifactName := range step.InputMapping {
+		taskNames[artifactName] = name
+	}
+
+	inputs := []atc.TaskInputConfig{}
+	findStep(job.Plan, func(step atc.PlanConfig) bool {
+		if step.Get != "" {
+			name := step.Get
+			if taskName, ok := taskNames[name]; ok {
+				name = taskName
+			}
+
+			if !TaskInputsContainsName(inputs, name) {
+				inputs = append(inputs, atc.TaskInputConfig{Name: name})
+			}
+		}
+
+		return false
+	})
+
+	if !TaskInputsContainsName(inputs, inputName) {
+		return Task{}, nil, fmt.Errorf("task config is loaded from `%s`, which is not an input of the job", step.TaskConfigPath)
+	}
+
+	return Task{
+		Name:       "one-off",
+		ConfigPath: inputName + "/" + path,
+		Params:     step.Params,
+	}, inputs, nil
+}
+
+// jobTaskConfigFile determines the task's input that the step's config file
+// is in, following the step's input mapping, and the path within it.
+func jobTaskConfigFile(step atc.PlanConfig) (string, string, error) {
+	segments := strings.SplitN(step.TaskConfigPath, "/", 2)
+	if len(segments) != 2 {
+		return "", "", fmt.Errorf("invalid task config path `%s`", step.TaskConfigPath)
+	}
+
+	artifactName, path := segments[0], segments[1]
+
+	inputName := artifactName
+	for name, mapped := range step.InputMapping {
+		if mapped == artifactName {
+			inputName = name
+		}
+	}
+
+	return inputName, path, nil
+}
+
+func applyStepParams(config atc.TaskConfig, stepParams atc.Params) (atc.TaskConfig, error) {
+	if len(stepParams) == 0 {
+		return config, nil
+	}
+
+	params := map[string]string{}
+	for k, v := range config.Params {
+		params[k] = v
+	}
+
+	for k, v := range stepParams {
+		if str, ok := v.(string); ok {
+			params[k] = str
+		} else {
+			payload, err := json.Marshal(v)
+			if err != nil {
+				return atc.TaskConfig{}, fmt.Errorf("invalid param `%s`: %s", k, err)
+			}
+
+			params[k] = string(payload)
+		}
+	}
+
+	config.Params = params
+
+	return config, nil
+}
@@ -0,0 +1,168 @@
+package executehelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/atc"
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JobTaskConfig", func() {
+	var step atc.PlanConfig
+
+	BeforeEach(func() {
+		step = atc.PlanConfig{
+			Task: "some-task",
+			TaskConfig: &atc.TaskConfig{
+				Platform: "linux",
+				Params: map[string]string{
+					"FOO": "foo",
+					"BAR": "bar",
+				},
+				Run: atc.TaskRunConfig{Path: "ls"},
+			},
+			Params: atc.Params{
+				"BAR":  "overridden",
+				"BAZ":  42,
+				"JSON": map[string]interface{}{"a": "b"},
+			},
+		}
+	})
+
+	It("applies the step's params on top of the task's", func() {
+		config, found, err := JobTaskConfig(step, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		Expect(config.Params).To(Equal(map[string]string{
+			"FOO":  "foo",
+			"BAR":  "overridden",
+			"BAZ":  "42",
+			"JSON": `{"a":"b"}`,
+		}))
+	})
+
+	Context("when the config is loaded from a file", func() {
+		var inputDir string
+
+		BeforeEach(func() {
+			var err error
+			inputDir, err = ioutil.TempDir("", "job-task")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.MkdirAll(filepath.Join(inputDir, "ci"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(inputDir, "ci", "task.yml"), []byte(`---
+platform: linux
+run:
+  path: ./ci/run
+`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			step.TaskConfig = nil
+			step.TaskConfigPath = "source/ci/task.yml"
+			step.Params = nil
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(inputDir)
+		})
+
+		It("reads it from the matching local input", func() {
+			config, found, err := JobTaskConfig(step, []flaghelpers.InputPairFlag{
+				{Name: "source", Path: inputDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(config.Run.Path).To(Equal("./ci/run"))
+		})
+
+		It("follows the step's input mapping", func() {
+			step.InputMapping = map[string]string{"code": "source"}
+
+			config, found, err := JobTaskConfig(step, []flaghelpers.InputPairFlag{
+				{Name: "code", Path: inputDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(config.Run.Path).To(Equal("./ci/run"))
+		})
+
+		It("is not found when the input is not provided", func() {
+			_, found, err := JobTaskConfig(step, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("RemoteJobTask", func() {
+	var job atc.JobConfig
+	var step atc.PlanConfig
+
+	BeforeEach(func() {
+		step = atc.PlanConfig{
+			Task:           "some-task",
+			TaskConfigPath: "source/ci/task.yml",
+			Params:         atc.Params{"FOO": "foo"},
+		}
+
+		job = atc.JobConfig{
+			Name: "some-job",
+			Plan: atc.PlanSequence{
+				{
+					Aggregate: &atc.PlanSequence{
+						{Get: "source"},
+						{Get: "other"},
+					},
+				},
+				step,
+			},
+		}
+	})
+
+	It("loads the config from the job's input, giving the task all of the job's inputs", func() {
+		task, inputs, err := RemoteJobTask(job, step)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(task).To(Equal(Task{
+			Name:       "one-off",
+			ConfigPath: "source/ci/task.yml",
+			Params:     atc.Params{"FOO": "foo"},
+		}))
+
+		Expect(inputs).To(Equal([]atc.TaskInputConfig{
+			{Name: "source"},
+			{Name: "other"},
+		}))
+	})
+
+	It("follows the step's input mapping", func() {
+		step.InputMapping = map[string]string{"code": "source"}
+
+		task, inputs, err := RemoteJobTask(job, step)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(task.ConfigPath).To(Equal("code/ci/task.yml"))
+		Expect(inputs).To(Equal([]atc.TaskInputConfig{
+			{Name: "code"},
+			{Name: "other"},
+		}))
+	})
+
+	It("errors when the file is not in one of the job's inputs", func() {
+		step.TaskConfigPath = "bogus/ci/task.yml"
+
+		_, _, err := RemoteJobTask(job, step)
+		Expect(err).To(MatchError("task config is loaded from `bogus/ci/task.yml`, which is not an input of the job"))
+	})
+})
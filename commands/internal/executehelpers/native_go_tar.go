@@ -2,13 +2,12 @@ package executehelpers
 
 import (
 	"archive/tar"
-	"compress/gzip"
 	"io"
 	"os"
 	"path/filepath"
 )
 
-func nativeTarGZStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
+func nativeTarStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
 	r, w := io.Pipe()
 
 	absWorkDir, err := filepath.Abs(workDir)
@@ -16,13 +15,10 @@ func nativeTarGZStreamFrom(workDir string, paths []string) (io.ReadCloser, error
 		return nil, err
 	}
 
-	gzWriter := gzip.NewWriter(w)
-
-	tarWriter := tar.NewWriter(gzWriter)
+	tarWriter := tar.NewWriter(w)
 
 	go func() {
 		defer w.Close()
-		defer gzWriter.Close()
 		defer tarWriter.Close()
 
 		for _, p := range paths {
@@ -37,6 +33,28 @@ func nativeTarGZStreamFrom(workDir string, paths []string) (io.ReadCloser, error
 	return r, nil
 }
 
+// nativeTarEntriesStreamFrom archives exactly the given entries, without
+// descending into directories.
+func nativeTarEntriesStreamFrom(workDir string, entries []string) (io.ReadCloser, error) {
+	r, w := io.Pipe()
+
+	tarWriter := tar.NewWriter(w)
+
+	go func() {
+		for _, entry := range entries {
+			err := addTarFile(filepath.Join(workDir, entry), entry, tarWriter)
+			if err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+
+		w.CloseWithError(tarWriter.Close())
+	}()
+
+	return r, nil
+}
+
 func writePathToTar(tw *tar.Writer, workDir string, srcPath string) error {
 	return filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -15,6 +15,16 @@ type Output struct {
 	Pipe atc.Pipe
 }
 
+func WritesToStdout(outputs []Output) bool {
+	for _, output := range outputs {
+		if output.Path == StdoutPath {
+			return true
+		}
+	}
+
+	return false
+}
+
 func DetermineOutputs(
 	client concourse.Client,
 	taskOutputs []atc.TaskOutputConfig,
@@ -22,6 +32,7 @@ func DetermineOutputs(
 ) ([]Output, error) {
 
 	outputs := []Output{}
+	streamingToStdout := false
 
 	for _, i := range outputMappings {
 		outputName := i.Name
@@ -36,9 +47,19 @@ func DetermineOutputs(
 			return nil, fmt.Errorf("unknown output '%s'", outputName)
 		}
 
-		absPath, err := filepath.Abs(i.Path)
-		if err != nil {
-			return nil, err
+		absPath := i.Path
+		if absPath == StdoutPath {
+			if streamingToStdout {
+				return nil, fmt.Errorf("only one output can be written to stdout")
+			}
+
+			streamingToStdout = true
+		} else {
+			var err error
+			absPath, err = filepath.Abs(i.Path)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		pipe, err := client.CreatePipe()
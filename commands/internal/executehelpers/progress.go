@@ -0,0 +1,357 @@
+package executehelpers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	ttyProgressInterval   = 200 * time.Millisecond
+	plainProgressInterval = 5 * time.Second
+
+	progressBarWidth = 20
+)
+
+// ProgressRenderer reports the progress of input uploads and output downloads.
+//
+// On a terminal it redraws a bar per transfer below the build log, clearing
+// them whenever the log (written through Wrap) needs the screen. Otherwise it
+// periodically prints a plain line per transfer.
+type ProgressRenderer struct {
+	dst      io.Writer
+	tty      bool
+	interval time.Duration
+
+	lock      sync.Mutex
+	transfers []*Transfer
+	drawn     int
+	midLine   bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type Transfer struct {
+	renderer *ProgressRenderer
+
+	name  string
+	verb  string
+	total int64
+
+	started time.Time
+
+	lock     sync.Mutex
+	current  int64
+	finished time.Time
+}
+
+func NewProgressRenderer(dst *os.File) *ProgressRenderer {
+	tty := isatty.IsTerminal(dst.Fd())
+
+	interval := plainProgressInterval
+	if tty {
+		interval = ttyProgressInterval
+	}
+
+	return newProgressRenderer(dst, tty, interval)
+}
+
+func newProgressRenderer(dst io.Writer, tty bool, interval time.Duration) *ProgressRenderer {
+	renderer := &ProgressRenderer{
+		dst:      dst,
+		tty:      tty,
+		interval: interval,
+
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go renderer.loop()
+
+	return renderer
+}
+
+// Track registers a new transfer. A total of zero or less means the size is
+// not known up front, in which case no percentage or ETA is shown.
+func (renderer *ProgressRenderer) Track(verb string, name string, total int64) *Transfer {
+	transfer := &Transfer{
+		renderer: renderer,
+
+		name:  name,
+		verb:  verb,
+		total: total,
+
+		started: time.Now(),
+	}
+
+	renderer.lock.Lock()
+	renderer.transfers = append(renderer.transfers, transfer)
+	renderer.lock.Unlock()
+
+	return transfer
+}
+
+// Wrap returns a writer which keeps the progress bars out of the way of
+// anything written to dst, e.g. the build log.
+func (renderer *ProgressRenderer) Wrap(dst io.Writer) io.Writer {
+	if !renderer.tty {
+		return dst
+	}
+
+	return progressWriter{renderer: renderer, dst: dst}
+}
+
+// Stop clears any bars from the terminal and stops rendering.
+func (renderer *ProgressRenderer) Stop() {
+	close(renderer.stop)
+	<-renderer.done
+
+	renderer.lock.Lock()
+	renderer.clear()
+	renderer.lock.Unlock()
+}
+
+func (renderer *ProgressRenderer) loop() {
+	defer close(renderer.done)
+
+	ticker := time.NewTicker(renderer.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			renderer.lock.Lock()
+			if renderer.tty {
+				renderer.clear()
+				renderer.draw()
+			} else {
+				for _, transfer := range renderer.transfers {
+					fmt.Fprintln(renderer.dst, transfer.status())
+				}
+			}
+			renderer.lock.Unlock()
+
+		case <-renderer.stop:
+			return
+		}
+	}
+}
+
+func (renderer *ProgressRenderer) finish(transfer *Transfer, err error) {
+	renderer.lock.Lock()
+	defer renderer.lock.Unlock()
+
+	for i, t := range renderer.transfers {
+		if t == transfer {
+			renderer.transfers = append(renderer.transfers[:i], renderer.transfers[i+1:]...)
+			break
+		}
+	}
+
+	renderer.clear()
+	fmt.Fprintln(renderer.dst, transfer.summary(err))
+	renderer.draw()
+}
+
+// clear and draw must be called with the lock held.
+func (renderer *ProgressRenderer) clear() {
+	for ; renderer.drawn > 0; renderer.drawn-- {
+		fmt.Fprint(renderer.dst, "\x1b[1A\x1b[2K")
+	}
+}
+
+func (renderer *ProgressRenderer) draw() {
+	if !renderer.tty || renderer.midLine {
+		return
+	}
+
+	for _, transfer := range renderer.transfers {
+		fmt.Fprintln(renderer.dst, transfer.bar())
+		renderer.drawn++
+	}
+}
+
+type progressWriter struct {
+	renderer *ProgressRenderer
+	dst      io.Writer
+}
+
+func (writer progressWriter) Write(p []byte) (int, error) {
+	writer.renderer.lock.Lock()
+	defer writer.renderer.lock.Unlock()
+
+	writer.renderer.clear()
+
+	n, err := writer.dst.Write(p)
+
+	if len(p) > 0 {
+		writer.renderer.midLine = p[len(p)-1] != '\n'
+	}
+
+	writer.renderer.draw()
+
+	return n, err
+}
+
+// Reader counts everything read from r towards the transfer.
+func (transfer *Transfer) Reader(r io.Reader) io.Reader {
+	return transferReader{transfer: transfer, r: r}
+}
+
+func (transfer *Transfer) Add(n int64) {
+	transfer.lock.Lock()
+	transfer.current += n
+	transfer.lock.Unlock()
+}
+
+// Done prints a summary of the transfer, or that it failed if err is not nil,
+// and stops tracking it.
+func (transfer *Transfer) Done(err error) {
+	transfer.lock.Lock()
+	transfer.finished = time.Now()
+	transfer.lock.Unlock()
+
+	transfer.renderer.finish(transfer, err)
+}
+
+func (transfer *Transfer) snapshot() (int64, time.Duration) {
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+
+	end := transfer.finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	return transfer.current, end.Sub(transfer.started)
+}
+
+func (transfer *Transfer) status() string {
+	current, elapsed := transfer.snapshot()
+	rate := transferRate(current, elapsed)
+
+	status := fmt.Sprintf("%s %s: %s", transfer.verb, transfer.name, humanBytes(current))
+	if transfer.total > 0 {
+		status += fmt.Sprintf(" of %s", humanBytes(transfer.total))
+	}
+
+	status += fmt.Sprintf(" (%s/s", humanBytes(int64(rate)))
+	if eta, ok := transfer.eta(current, rate); ok {
+		status += fmt.Sprintf(", eta %s", eta)
+	}
+
+	return status + ")"
+}
+
+func (transfer *Transfer) bar() string {
+	current, elapsed := transfer.snapshot()
+	rate := transferRate(current, elapsed)
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%s %s ", transfer.verb, transfer.name)
+
+	if transfer.total > 0 {
+		// the total may be an estimate, so never go past a full bar
+		percent := 100 * current / transfer.total
+		if percent > 100 {
+			percent = 100
+		}
+
+		filled := progressBarWidth * int(percent) / 100
+
+		fmt.Fprintf(
+			buf,
+			"[%s%s] %3d%% %s/%s",
+			strings.Repeat("=", filled),
+			strings.Repeat(" ", progressBarWidth-filled),
+			percent,
+			humanBytes(current),
+			humanBytes(transfer.total),
+		)
+	} else {
+		fmt.Fprintf(buf, "%s", humanBytes(current))
+	}
+
+	fmt.Fprintf(buf, " %s/s", humanBytes(int64(rate)))
+
+	if eta, ok := transfer.eta(current, rate); ok {
+		fmt.Fprintf(buf, " eta %s", eta)
+	}
+
+	return buf.String()
+}
+
+func (transfer *Transfer) summary(err error) string {
+	current, elapsed := transfer.snapshot()
+	elapsed -= elapsed % (100 * time.Millisecond)
+
+	if err != nil {
+		return fmt.Sprintf(
+			"%s %s failed after %s in %s",
+			transfer.verb,
+			transfer.name,
+			humanBytes(current),
+			elapsed,
+		)
+	}
+
+	return fmt.Sprintf(
+		"finished %s %s: %s in %s",
+		transfer.verb,
+		transfer.name,
+		humanBytes(current),
+		elapsed,
+	)
+}
+
+func (transfer *Transfer) eta(current int64, rate float64) (time.Duration, bool) {
+	if transfer.total <= 0 || rate <= 0 || current >= transfer.total {
+		return 0, false
+	}
+
+	remaining := float64(transfer.total-current) / rate
+
+	return time.Duration(remaining) * time.Second, true
+}
+
+type transferReader struct {
+	transfer *Transfer
+	r        io.Reader
+}
+
+func (reader transferReader) Read(p []byte) (int, error) {
+	n, err := reader.r.Read(p)
+	reader.transfer.Add(int64(n))
+	return n, err
+}
+
+func transferRate(size int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(size) / elapsed.Seconds()
+}
+
+func humanBytes(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
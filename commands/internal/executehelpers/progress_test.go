@@ -0,0 +1,123 @@
+package executehelpers_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("ProgressRenderer", func() {
+	var dst *os.File
+	var renderer *ProgressRenderer
+
+	BeforeEach(func() {
+		var err error
+		dst, err = ioutil.TempFile("", "fly-progress")
+		Expect(err).NotTo(HaveOccurred())
+
+		renderer = NewProgressRenderer(dst)
+	})
+
+	AfterEach(func() {
+		renderer.Stop()
+
+		dst.Close()
+		os.Remove(dst.Name())
+	})
+
+	readOutput := func() string {
+		contents, err := ioutil.ReadFile(dst.Name())
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	Context("when not writing to a terminal", func() {
+		It("passes writes through untouched", func() {
+			buf := new(bytes.Buffer)
+			Expect(renderer.Wrap(buf)).To(BeIdenticalTo(buf))
+		})
+
+		It("prints a summary when a transfer is done", func() {
+			transfer := renderer.Track("uploading", "some-input", 0)
+
+			_, err := ioutil.ReadAll(transfer.Reader(bytes.NewBufferString("some-bits")))
+			Expect(err).NotTo(HaveOccurred())
+
+			transfer.Done(nil)
+
+			Expect(readOutput()).To(MatchRegexp(`^finished uploading some-input: 9B in \S+\n$`))
+		})
+
+		It("prints that a transfer failed instead of a summary", func() {
+			transfer := renderer.Track("downloading", "some-output", 0)
+			transfer.Add(9)
+
+			transfer.Done(errors.New("connection reset"))
+
+			Expect(readOutput()).To(MatchRegexp(`^downloading some-output failed after 9B in \S+\n$`))
+		})
+	})
+
+	Context("when writing to a terminal", func() {
+		var buf *gbytes.Buffer
+		var ttyRenderer *ProgressRenderer
+
+		BeforeEach(func() {
+			buf = gbytes.NewBuffer()
+			ttyRenderer = NewTerminalProgressRenderer(buf, 10*time.Millisecond)
+		})
+
+		AfterEach(func() {
+			ttyRenderer.Stop()
+		})
+
+		It("draws a bar with the percentage and ETA when the total is known", func() {
+			transfer := ttyRenderer.Track("uploading", "some-input", 100)
+			transfer.Add(50)
+
+			Eventually(buf).Should(gbytes.Say(`uploading some-input \[={10} {10}\]  50% 50B/100B \S+/s eta \S+\n`))
+
+			transfer.Done(nil)
+
+			Eventually(buf).Should(gbytes.Say(`finished uploading some-input: 50B in \S+\n`))
+		})
+
+		It("never goes past a full bar when the total is exceeded", func() {
+			transfer := ttyRenderer.Track("uploading", "some-input", 100)
+			transfer.Add(150)
+
+			Eventually(buf).Should(gbytes.Say(`uploading some-input \[={20}\] 100% 150B/100B \S+/s\n`))
+
+			transfer.Done(nil)
+		})
+
+		It("draws only the transferred size when the total is unknown", func() {
+			transfer := ttyRenderer.Track("downloading", "some-output", 0)
+			transfer.Add(50)
+
+			Eventually(buf).Should(gbytes.Say(`downloading some-output 50B \S+/s\n`))
+
+			transfer.Done(nil)
+		})
+
+		It("clears the bars before anything is written through Wrap", func() {
+			transfer := ttyRenderer.Track("uploading", "some-input", 100)
+			defer transfer.Done(nil)
+
+			Eventually(buf).Should(gbytes.Say(`uploading some-input`))
+
+			_, err := ttyRenderer.Wrap(buf).Write([]byte("some log line\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(buf).Should(gbytes.Say("\x1b\\[1A\x1b\\[2Ksome log line\n"))
+		})
+	})
+})
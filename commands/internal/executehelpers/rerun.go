@@ -0,0 +1,355 @@
+package executehelpers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+// FetchInputsFromBuild determines the inputs of a job's build from the
+// pipeline config, pinned to the versions the build used. Unlike the job's
+// current build inputs, these are available even when the job can not be
+// scheduled.
+//
+// Puts are not rerun, so the artifacts they leave for the steps after them
+// are provided as inputs too, pinned to the versions the build put.
+func FetchInputsFromBuild(client concourse.Client, config atc.Config, job atc.JobConfig, build atc.Build) ([]Input, error) {
+	resources, found, err := client.BuildResources(build.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errors.New("build resources not found")
+	}
+
+	inputs := []Input{}
+	for _, buildResource := range resources.Inputs {
+		resource, found := config.Resources.Lookup(buildResource.Resource)
+		if !found {
+			return nil, fmt.Errorf("resource `%s` of input `%s` is no longer configured for the pipeline", buildResource.Resource, buildResource.Name)
+		}
+
+		step, found := findStep(job.Plan, func(step atc.PlanConfig) bool {
+			return step.Get == buildResource.Name
+		})
+		if !found {
+			return nil, fmt.Errorf("input `%s` of build %s is no longer configured for the job", buildResource.Name, build.Name)
+		}
+
+		inputs = append(inputs, Input{
+			Name: buildResource.Name,
+			BuildInput: atc.BuildInput{
+				Name:     buildResource.Name,
+				Resource: resource.Name,
+				Type:     resource.Type,
+				Source:   resource.Source,
+				Params:   step.Params,
+				Version:  buildResource.Version,
+				Tags:     step.Tags,
+			},
+			ResourceTypes: config.ResourceTypes,
+		})
+	}
+
+	putInputs, err := putInputsFromBuild(config, job, build, resources.Outputs, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(inputs, putInputs...), nil
+}
+
+func putInputsFromBuild(config atc.Config, job atc.JobConfig, build atc.Build, outputs []atc.PublicBuildOutput, inputs []Input) ([]Input, error) {
+	provided := map[string]bool{}
+	for _, input := range inputs {
+		provided[input.Name] = true
+	}
+
+	putVersions := map[string]atc.Version{}
+	for _, output := range outputs {
+		putVersions[output.Resource] = output.Version
+	}
+
+	puts := []atc.PlanConfig{}
+	findStep(job.Plan, func(step atc.PlanConfig) bool {
+		if step.Put != "" {
+			puts = append(puts, step)
+		}
+
+		return false
+	})
+
+	putInputs := []Input{}
+	for _, step := range puts {
+		// a get of the same name already provides the artifact
+		if provided[step.Put] {
+			continue
+		}
+
+		resourceName := step.Resource
+		if resourceName == "" {
+			resourceName = step.Put
+		}
+
+		version, found := putVersions[resourceName]
+		if !found {
+			_, consumed := findStep(job.Plan, func(consumer atc.PlanConfig) bool {
+				return consumesArtifact(consumer, step.Put)
+			})
+			if consumed {
+				return nil, fmt.Errorf("put `%s` did not produce a version in build %s, so the steps consuming it can not be rerun", step.Put, build.Name)
+			}
+
+			continue
+		}
+
+		resource, found := config.Resources.Lookup(resourceName)
+		if !found {
+			return nil, fmt.Errorf("resource `%s` of put `%s` is no longer configured for the pipeline", resourceName, step.Put)
+		}
+
+		provided[step.Put] = true
+
+		putInputs = append(putInputs, Input{
+			Name: step.Put,
+			BuildInput: atc.BuildInput{
+				Name:     step.Put,
+				Resource: resource.Name,
+				Type:     resource.Type,
+				Source:   resource.Source,
+				Version:  version,
+				Tags:     step.Tags,
+			},
+			ResourceTypes: config.ResourceTypes,
+		})
+	}
+
+	return putInputs, nil
+}
+
+// consumesArtifact determines whether the task step is known to use the
+// artifact, through its input mapping, image or inline config. The inputs of
+// a config loaded from a file are not known.
+func consumesArtifact(step atc.PlanConfig, artifact string) bool {
+	if step.Task == "" {
+		return false
+	}
+
+	if step.ImageArtifactName == artifact {
+		return true
+	}
+
+	for _, mapped := range step.InputMapping {
+		if mapped == artifact {
+			return true
+		}
+	}
+
+	if step.TaskConfig != nil {
+		for _, input := range step.TaskConfig.Inputs {
+			if _, remapped := step.InputMapping[input.Name]; !remapped && input.Name == artifact {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func CreateRerunBuild(
+	client concourse.Client,
+	inputs []Input,
+	job atc.JobConfig,
+	resourceTypes atc.ResourceTypes,
+	target rc.TargetName,
+) (atc.Build, error) {
+	fact := atc.NewPlanFactory(time.Now().Unix())
+
+	targetProps, err := rc.SelectTarget(target)
+	if err != nil {
+		return atc.Build{}, err
+	}
+
+	steps := atc.DoPlan{
+		fact.NewPlan(inputGetPlans(fact, inputs, targetProps)),
+	}
+
+	for _, config := range job.Plan {
+		plan, ok, err := rerunPlan(fact, config, resourceTypes)
+		if err != nil {
+			return atc.Build{}, err
+		}
+
+		if ok {
+			steps = append(steps, plan)
+		}
+	}
+
+	if len(steps) == 1 {
+		return atc.Build{}, fmt.Errorf("job `%s` has no task steps to rerun", job.Name)
+	}
+
+	return client.CreateBuild(fact.NewPlan(steps))
+}
+
+// rerunPlan converts a step of a job's plan into the equivalent one-off plan.
+// Gets, and the artifacts left by puts, are provided up front with their
+// pinned versions and puts are skipped, so only the tasks (and the steps that
+// compose them) are kept, along with their hooks, retries and timeouts. The
+// hooks of a get still run, as it always succeeds; those of a put are skipped
+// with it.
+func rerunPlan(fact atc.PlanFactory, config atc.PlanConfig, resourceTypes atc.ResourceTypes) (atc.Plan, bool, error) {
+	if config.Attempts > 1 {
+		single := config
+		single.Attempts = 0
+
+		retry := atc.RetryPlan{}
+		for i := 0; i < config.Attempts; i++ {
+			plan, ok, err := rerunPlan(fact, single, resourceTypes)
+			if err != nil || !ok {
+				return atc.Plan{}, ok, err
+			}
+
+			retry = append(retry, plan)
+		}
+
+		return fact.NewPlan(retry), true, nil
+	}
+
+	plan, ok, err := rerunStep(fact, config, resourceTypes)
+	if err != nil || config.Put != "" {
+		return atc.Plan{}, false, err
+	}
+
+	if ok && config.Timeout != "" {
+		plan = fact.NewPlan(atc.TimeoutPlan{
+			Duration: config.Timeout,
+			Step:     plan,
+		})
+	}
+
+	hooks := []struct {
+		config *atc.PlanConfig
+		wrap   func(step atc.Plan, next atc.Plan) atc.Plan
+
+		// whether the hook still runs when there is no step left to attach it
+		// to, i.e. the step was a get
+		runsAlone bool
+	}{
+		{
+			config: config.Success,
+			wrap: func(step atc.Plan, next atc.Plan) atc.Plan {
+				return fact.NewPlan(atc.OnSuccessPlan{Step: step, Next: next})
+			},
+			runsAlone: true,
+		},
+		{
+			config: config.Failure,
+			wrap: func(step atc.Plan, next atc.Plan) atc.Plan {
+				return fact.NewPlan(atc.OnFailurePlan{Step: step, Next: next})
+			},
+		},
+		{
+			config: config.Ensure,
+			wrap: func(step atc.Plan, next atc.Plan) atc.Plan {
+				return fact.NewPlan(atc.EnsurePlan{Step: step, Next: next})
+			},
+			runsAlone: true,
+		},
+	}
+
+	for _, hook := range hooks {
+		if hook.config == nil {
+			continue
+		}
+
+		next, hookOK, err := rerunPlan(fact, *hook.config, resourceTypes)
+		if err != nil {
+			return atc.Plan{}, false, err
+		}
+
+		switch {
+		case !hookOK:
+		case ok:
+			plan = hook.wrap(plan, next)
+		case hook.runsAlone:
+			plan, ok = next, true
+		}
+	}
+
+	return plan, ok, nil
+}
+
+func rerunStep(fact atc.PlanFactory, config atc.PlanConfig, resourceTypes atc.ResourceTypes) (atc.Plan, bool, error) {
+	switch {
+	case config.Do != nil:
+		do := atc.DoPlan{}
+		for _, step := range *config.Do {
+			plan, ok, err := rerunPlan(fact, step, resourceTypes)
+			if err != nil {
+				return atc.Plan{}, false, err
+			}
+
+			if ok {
+				do = append(do, plan)
+			}
+		}
+
+		if len(do) == 0 {
+			return atc.Plan{}, false, nil
+		}
+
+		return fact.NewPlan(do), true, nil
+
+	case config.Aggregate != nil:
+		aggregate := atc.AggregatePlan{}
+		for _, step := range *config.Aggregate {
+			plan, ok, err := rerunPlan(fact, step, resourceTypes)
+			if err != nil {
+				return atc.Plan{}, false, err
+			}
+
+			if ok {
+				aggregate = append(aggregate, plan)
+			}
+		}
+
+		if len(aggregate) == 0 {
+			return atc.Plan{}, false, nil
+		}
+
+		return fact.NewPlan(aggregate), true, nil
+
+	case config.Try != nil:
+		plan, ok, err := rerunPlan(fact, *config.Try, resourceTypes)
+		if err != nil || !ok {
+			return atc.Plan{}, false, err
+		}
+
+		return fact.NewPlan(atc.TryPlan{Step: plan}), true, nil
+
+	case config.Task != "":
+		return fact.NewPlan(atc.TaskPlan{
+			Name:              config.Task,
+			Privileged:        config.Privileged,
+			Tags:              config.Tags,
+			ConfigPath:        config.TaskConfigPath,
+			Config:            config.TaskConfig,
+			Params:            config.Params,
+			InputMapping:      config.InputMapping,
+			OutputMapping:     config.OutputMapping,
+			ImageArtifactName: config.ImageArtifactName,
+			ResourceTypes:     resourceTypes,
+		}), true, nil
+
+	case config.Get != "", config.Put != "":
+		return atc.Plan{}, false, nil
+	}
+
+	return atc.Plan{}, false, errors.New("can not rerun a step of an unknown kind")
+}
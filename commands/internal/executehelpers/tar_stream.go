@@ -16,33 +16,98 @@ import (
 )
 
 func tarStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
-	var archive io.ReadCloser
+	if tarPath, err := exec.LookPath("tar"); err == nil {
+		return tarCmdStreamFrom(tarPath, workDir, paths)
+	}
 
+	return nativeTarStreamFrom(workDir, paths)
+}
+
+// tarEntriesStreamFrom archives exactly the given entries, without descending
+// into directories.
+func tarEntriesStreamFrom(workDir string, entries []string) (io.ReadCloser, error) {
 	if tarPath, err := exec.LookPath("tar"); err == nil {
-		tarCmd := exec.Command(tarPath, "-czf", "-", "--null", "-T", "-")
-		tarCmd.Dir = workDir
-		tarCmd.Stderr = os.Stderr
+		return tarCmdStreamFrom(tarPath, workDir, entries, "--no-recursion")
+	}
 
-		tarCmd.Stdin = bytes.NewBufferString(strings.Join(paths, "\x00"))
+	return nativeTarEntriesStreamFrom(workDir, entries)
+}
 
-		tarCmd.SysProcAttr = &syscall.SysProcAttr{
-			Setpgid: true,
-		}
+func tarCmdStreamFrom(tarPath string, workDir string, paths []string, flags ...string) (io.ReadCloser, error) {
+	args := append([]string{"-cf", "-"}, flags...)
+	args = append(args, "--null", "-T", "-")
 
-		archive, err = tarCmd.StdoutPipe()
-		if err != nil {
-			return nil, fmt.Errorf("could not create tar pipe: %s", err)
+	tarCmd := exec.Command(tarPath, args...)
+	tarCmd.Dir = workDir
+	tarCmd.Stderr = os.Stderr
+
+	tarCmd.Stdin = bytes.NewBufferString(strings.Join(paths, "\x00"))
+
+	tarCmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+
+	archive, err := tarCmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not create tar pipe: %s", err)
+	}
+
+	err = tarCmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("could not run tar: %s", err)
+	}
+
+	return &tarCmdReadCloser{ReadCloser: archive, cmd: tarCmd}, nil
+}
+
+// tarCmdReadCloser reports tar exiting unsuccessfully instead of just ending
+// the stream, so that a truncated archive is not mistaken for a complete one.
+type tarCmdReadCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+
+	waited  bool
+	waitErr error
+}
+
+func (archive *tarCmdReadCloser) Read(p []byte) (int, error) {
+	n, err := archive.ReadCloser.Read(p)
+	if err == io.EOF {
+		if waitErr := archive.wait(); waitErr != nil {
+			return n, waitErr
 		}
+	}
+
+	return n, err
+}
+
+// Close stops reading the archive and waits for tar to exit, returning its
+// error if it did not succeed.
+func (archive *tarCmdReadCloser) Close() error {
+	if archive.waited {
+		return archive.waitErr
+	}
+
+	closeErr := archive.ReadCloser.Close()
+
+	if waitErr := archive.wait(); waitErr != nil {
+		return waitErr
+	}
+
+	return closeErr
+}
+
+func (archive *tarCmdReadCloser) wait() error {
+	if !archive.waited {
+		archive.waited = true
 
-		err = tarCmd.Start()
+		err := archive.cmd.Wait()
 		if err != nil {
-			return nil, fmt.Errorf("could not run tar: %s", err)
+			archive.waitErr = fmt.Errorf("tar failed: %s", err)
 		}
-	} else {
-		return nativeTarGZStreamFrom(workDir, paths)
 	}
 
-	return archive, nil
+	return archive.waitErr
 }
 
 func tarStreamTo(workDir string, stream io.Reader) error {
@@ -10,7 +10,11 @@ import (
 )
 
 func tarStreamFrom(workDir string, paths []string) (io.ReadCloser, error) {
-	return nativeTarGZStreamFrom(workDir, paths)
+	return nativeTarStreamFrom(workDir, paths)
+}
+
+func tarEntriesStreamFrom(workDir string, entries []string) (io.ReadCloser, error) {
+	return nativeTarEntriesStreamFrom(workDir, entries)
 }
 
 func tarStreamTo(workDir string, stream io.Reader) error {
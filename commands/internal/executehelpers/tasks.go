@@ -0,0 +1,80 @@
+package executehelpers
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/concourse/atc"
+)
+
+// Task is one of the tasks that a one-off build runs in order. The outputs of
+// each task are available as inputs to those that follow it.
+type Task struct {
+	Name   string
+	Config atc.TaskConfig
+
+	// ConfigPath is set when the build loads the config from a file in one of
+	// its inputs instead, in which case Config is unknown and Params are
+	// applied on top of the loaded config
+	ConfigPath string
+	Params     atc.Params
+}
+
+// TaskNames names each task after its config file, e.g. build for
+// ci/build.yml, keeping the names unique.
+func TaskNames(configPaths []string) []string {
+	names := []string{}
+	seen := map[string]int{}
+
+	for _, path := range configPaths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+		seen[name]++
+		if seen[name] > 1 {
+			name = name + "-" + strconv.Itoa(seen[name])
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// ExternalInputs determines the inputs that must be provided to the build,
+// i.e. those of the tasks that are not an output of an earlier task.
+func ExternalInputs(tasks []Task) []atc.TaskInputConfig {
+	inputs := []atc.TaskInputConfig{}
+
+	provided := map[string]bool{}
+	for _, task := range tasks {
+		for _, input := range task.Config.Inputs {
+			if !provided[input.Name] {
+				inputs = append(inputs, input)
+				provided[input.Name] = true
+			}
+		}
+
+		for _, output := range task.Config.Outputs {
+			provided[output.Name] = true
+		}
+	}
+
+	return inputs
+}
+
+func AllOutputs(tasks []Task) []atc.TaskOutputConfig {
+	outputs := []atc.TaskOutputConfig{}
+
+	seen := map[string]bool{}
+	for _, task := range tasks {
+		for _, output := range task.Config.Outputs {
+			if !seen[output.Name] {
+				outputs = append(outputs, output)
+				seen[output.Name] = true
+			}
+		}
+	}
+
+	return outputs
+}
@@ -0,0 +1,67 @@
+package executehelpers_test
+
+import (
+	"github.com/concourse/atc"
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tasks", func() {
+	Describe("TaskNames", func() {
+		It("names the tasks after their config files", func() {
+			Expect(TaskNames([]string{"ci/build.yml", "ci/test.yml", "other/test.yml"})).To(Equal([]string{
+				"build",
+				"test",
+				"test-2",
+			}))
+		})
+	})
+
+	Describe("ExternalInputs", func() {
+		It("leaves out inputs that an earlier task outputs", func() {
+			tasks := []Task{
+				{
+					Name: "build",
+					Config: atc.TaskConfig{
+						Inputs:  []atc.TaskInputConfig{{Name: "source"}},
+						Outputs: []atc.TaskOutputConfig{{Name: "binary"}},
+					},
+				},
+				{
+					Name: "test",
+					Config: atc.TaskConfig{
+						Inputs: []atc.TaskInputConfig{{Name: "source"}, {Name: "binary"}, {Name: "fixtures"}},
+					},
+				},
+			}
+
+			Expect(ExternalInputs(tasks)).To(Equal([]atc.TaskInputConfig{
+				{Name: "source"},
+				{Name: "fixtures"},
+			}))
+		})
+
+		It("requires inputs that are only output by a later task", func() {
+			tasks := []Task{
+				{
+					Name: "test",
+					Config: atc.TaskConfig{
+						Inputs: []atc.TaskInputConfig{{Name: "binary"}},
+					},
+				},
+				{
+					Name: "build",
+					Config: atc.TaskConfig{
+						Outputs: []atc.TaskOutputConfig{{Name: "binary"}},
+					},
+				},
+			}
+
+			Expect(ExternalInputs(tasks)).To(Equal([]atc.TaskInputConfig{
+				{Name: "binary"},
+			}))
+		})
+	})
+})
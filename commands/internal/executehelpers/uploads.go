@@ -3,55 +3,186 @@ package executehelpers
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"github.com/concourse/go-concourse/concourse"
 )
 
-func Upload(client concourse.Client, input Input, excludeIgnored bool) {
+func Upload(client concourse.Client, input Input, excludeIgnored bool, excludes []string, includes []string, deterministic bool, progress *ProgressRenderer) (err error) {
 	path := input.Path
 	pipe := input.Pipe
 
-	var files []string
-	var err error
+	files := []string{"."}
 
-	if excludeIgnored {
-		files, err = getGitFiles(path)
+	// an image is uploaded whole, as leaving out any of it would break it
+	if !input.UseAsImage {
+		rules, err := LoadIgnoreRules(path, excludes, includes)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "could not determine ignored files:", err)
-			return
+			return fmt.Errorf("could not load ignore rules: %s", err)
 		}
-	} else {
-		files = []string{"."}
+
+		files, err = getUploadFiles(path, excludeIgnored, rules)
+		if err != nil {
+			return fmt.Errorf("could not determine files to upload: %s", err)
+		}
+	}
+
+	size, err := tarSize(path, files)
+	if err != nil {
+		return fmt.Errorf("could not determine upload size: %s", err)
 	}
 
-	archive, err := tarStreamFrom(path, files)
+	var tarball io.ReadCloser
+	if deterministic {
+		tarball, err = deterministicTarStreamFrom(path, files)
+	} else {
+		tarball, err = tarStreamFrom(path, files)
+	}
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "could not create tar stream:", err)
-		return
+		return fmt.Errorf("could not create tar stream: %s", err)
 	}
 
+	defer tarball.Close()
+
+	// progress is measured before compression, as only the size of the
+	// uncompressed archive can be known up front
+	transfer := progress.Track("uploading", input.Name, size)
+	defer func() { transfer.Done(err) }()
+
+	archive := gzipStream(transfer.Reader(tarball))
 	defer archive.Close()
 
 	upload, err := http.NewRequest("PUT", pipe.WriteURL, archive)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	response, err := client.HTTPClient().Do(upload)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "upload request failed:", err)
-		return
+		return fmt.Errorf("upload request failed: %s", err)
 	}
 
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		fmt.Fprintln(os.Stderr, badResponseError("uploading bits", response))
+		return badResponseError("uploading bits", response)
+	}
+
+	return nil
+}
+
+// tarSize estimates the size of the uncompressed archive of the given paths: a
+// header block per entry, and the contents of regular files padded to whole
+// blocks. Long names may take extra header blocks, so this can fall short.
+func tarSize(dir string, paths []string) (int64, error) {
+	const blockSize = 512
+
+	size := int64(2 * blockSize)
+
+	for _, p := range paths {
+		err := filepath.Walk(filepath.Join(dir, p), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			size += blockSize
+
+			if info.Mode().IsRegular() {
+				size += (info.Size() + blockSize - 1) / blockSize * blockSize
+			}
+
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return size, nil
+}
+
+func gzipStream(r io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		gzWriter := gzip.NewWriter(pw)
+
+		_, err := io.Copy(gzWriter, r)
+		if err == nil {
+			err = gzWriter.Close()
+		}
+
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+func getUploadFiles(dir string, excludeIgnored bool, rules IgnoreRules) ([]string, error) {
+	if excludeIgnored {
+		gitFiles, err := getGitFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		files := []string{}
+		for _, file := range gitFiles {
+			if !rules.Excluded(file, false) {
+				files = append(files, file)
+			}
+		}
+
+		return files, nil
+	}
+
+	if len(rules) == 0 {
+		return []string{"."}, nil
+	}
+
+	descendIgnored := rules.hasNegations()
+
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if relative == "." {
+			return nil
+		}
+
+		excluded := rules.Excluded(relative, info.IsDir())
+
+		if info.IsDir() {
+			if excluded && !descendIgnored {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !excluded {
+			files = append(files, relative)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	return files, nil
 }
 
 func getGitFiles(dir string) ([]string, error) {
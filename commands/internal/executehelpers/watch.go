@@ -0,0 +1,122 @@
+package executehelpers
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// InputSnapshot records the state of every file that would be uploaded for a
+// set of local inputs, so that changes can be detected by polling.
+type InputSnapshot map[string]fileState
+
+type fileState struct {
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// SnapshotInputs records the state of the inputs' files. Outputs fetched into
+// an input are left out, so that fetching them does not count as a change.
+func SnapshotInputs(paths []string, outputPaths []string, excludeIgnored bool, excludes []string, includes []string) (InputSnapshot, error) {
+	snapshot := InputSnapshot{}
+
+	outputs := map[string]bool{}
+	for _, outputPath := range outputPaths {
+		if outputPath == StdoutPath {
+			continue
+		}
+
+		absPath, err := filepath.Abs(outputPath)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs[absPath] = true
+	}
+
+	for _, dir := range paths {
+		rules, err := LoadIgnoreRules(dir, excludes, includes)
+		if err != nil {
+			return nil, err
+		}
+
+		files, err := getUploadFiles(dir, excludeIgnored, rules)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			err := filepath.Walk(filepath.Join(dir, file), func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+
+				absPath, err := filepath.Abs(path)
+				if err != nil {
+					return err
+				}
+
+				if withinAny(absPath, outputs) {
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+
+					return nil
+				}
+
+				// a directory's own times change with its entries, which are
+				// recorded themselves, including any outputs fetched into it
+				if info.IsDir() {
+					snapshot[path] = fileState{mode: info.Mode()}
+					return nil
+				}
+
+				snapshot[path] = fileState{
+					size:    info.Size(),
+					mode:    info.Mode(),
+					modTime: info.ModTime(),
+				}
+
+				return nil
+			})
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+
+	return snapshot, nil
+}
+
+// withinAny reports whether the path is one of the given paths, or is inside
+// one of them.
+func withinAny(path string, paths map[string]bool) bool {
+	for {
+		if paths[path] {
+			return true
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return false
+		}
+
+		path = parent
+	}
+}
+
+func (snapshot InputSnapshot) Equal(other InputSnapshot) bool {
+	if len(snapshot) != len(other) {
+		return false
+	}
+
+	for path, state := range snapshot {
+		otherState, found := other[path]
+		if !found || !otherState.modTime.Equal(state.modTime) || otherState.size != state.size || otherState.mode != state.mode {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,104 @@
+package executehelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/concourse/fly/commands/internal/executehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SnapshotInputs", func() {
+	var dir string
+	var excludes []string
+	var outputs []string
+
+	var snapshot InputSnapshot
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-watch")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "some-file"), []byte("some-contents"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		excludes = []string{"*.log"}
+		outputs = []string{filepath.Join(dir, "out"), filepath.Join(dir, "out.tgz")}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		snapshot, err = SnapshotInputs([]string{dir}, outputs, false, excludes, nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	takeSnapshot := func() InputSnapshot {
+		current, err := SnapshotInputs([]string{dir}, outputs, false, excludes, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return current
+	}
+
+	It("is equal when nothing has changed", func() {
+		Expect(takeSnapshot().Equal(snapshot)).To(BeTrue())
+	})
+
+	It("detects modified files", func() {
+		later := time.Now().Add(time.Minute)
+		err := os.Chtimes(filepath.Join(dir, "some-file"), later, later)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(takeSnapshot().Equal(snapshot)).To(BeFalse())
+	})
+
+	It("detects new files", func() {
+		err := ioutil.WriteFile(filepath.Join(dir, "new-file"), []byte("new"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(takeSnapshot().Equal(snapshot)).To(BeFalse())
+	})
+
+	It("ignores outputs fetched into the input", func() {
+		err := os.MkdirAll(filepath.Join(dir, "out", "nested"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "out", "nested", "built"), []byte("built"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "out.tgz"), []byte("archived"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(takeSnapshot().Equal(snapshot)).To(BeTrue())
+	})
+
+	Context("when nothing is excluded", func() {
+		BeforeEach(func() {
+			excludes = nil
+		})
+
+		It("ignores outputs fetched into the input", func() {
+			err := os.MkdirAll(filepath.Join(dir, "out", "nested"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(dir, "out", "nested", "built"), []byte("built"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(takeSnapshot().Equal(snapshot)).To(BeTrue())
+		})
+	})
+
+	It("ignores excluded files", func() {
+		err := ioutil.WriteFile(filepath.Join(dir, "debug.log"), []byte("noise"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(takeSnapshot().Equal(snapshot)).To(BeTrue())
+	})
+})
@@ -0,0 +1,32 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JobBuildFlag", func() {
+	It("parses the pipeline, job and build", func() {
+		buildFlag := &JobBuildFlag{}
+
+		err := buildFlag.UnmarshalFlag("pipeline/job/42")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(*buildFlag).To(Equal(JobBuildFlag{
+			PipelineName: "pipeline",
+			JobName:      "job",
+			BuildName:    "42",
+		}))
+	})
+
+	Context("when there is no build specified", func() {
+		It("displays an error message", func() {
+			buildFlag := &JobBuildFlag{}
+
+			err := buildFlag.UnmarshalFlag("pipeline/job")
+			Expect(err).To(MatchError("argument format should be <pipeline>/<job>/<build>"))
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package flaghelpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/fly/template"
+	"gopkg.in/yaml.v2"
+)
+
+type YAMLVariablePairFlag struct {
+	Name  string
+	Value interface{}
+}
+
+func (pair *YAMLVariablePairFlag) UnmarshalFlag(value string) error {
+	vs := strings.SplitN(value, "=", 2)
+	if len(vs) != 2 {
+		return fmt.Errorf("invalid input pair '%s' (must be name=value)", value)
+	}
+
+	var raw interface{}
+	err := yaml.Unmarshal([]byte(vs[1]), &raw)
+	if err != nil {
+		return fmt.Errorf("invalid YAML value for '%s': %s", vs[0], err)
+	}
+
+	normalized, err := template.NormalizeValue(raw)
+	if err != nil {
+		return fmt.Errorf("invalid YAML value for '%s': %s", vs[0], err)
+	}
+
+	pair.Name = vs[0]
+	pair.Value = normalized
+
+	return nil
+}
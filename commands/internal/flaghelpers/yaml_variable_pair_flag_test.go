@@ -0,0 +1,49 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("YAMLVariablePairFlag", func() {
+	var flag *YAMLVariablePairFlag
+
+	BeforeEach(func() {
+		flag = &YAMLVariablePairFlag{}
+	})
+
+	It("parses the value as YAML", func() {
+		err := flag.UnmarshalFlag("source={uri: https://example.com, tags: [a, b], depth: 1}")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(flag.Name).To(Equal("source"))
+		Expect(flag.Value).To(Equal(map[string]interface{}{
+			"uri":   "https://example.com",
+			"tags":  []interface{}{"a", "b"},
+			"depth": 1,
+		}))
+	})
+
+	It("keeps scalar types", func() {
+		err := flag.UnmarshalFlag("private=true")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(flag.Value).To(Equal(true))
+	})
+
+	Context("when there is no value", func() {
+		It("returns an error", func() {
+			err := flag.UnmarshalFlag("source")
+			Expect(err).To(MatchError("invalid input pair 'source' (must be name=value)"))
+		})
+	})
+
+	Context("when the value is not valid YAML", func() {
+		It("returns an error", func() {
+			err := flag.UnmarshalFlag("source={uri")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
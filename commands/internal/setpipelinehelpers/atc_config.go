@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/atc/web"
@@ -22,6 +23,8 @@ type ATCConfig struct {
 	Client              concourse.Client
 	WebRequestGenerator *rata.RequestGenerator
 	SkipInteraction     bool
+	ShowSecrets         bool
+	SecretKeyPatterns   []string
 }
 
 func (atcConfig ATCConfig) ApplyConfigInteraction() bool {
@@ -38,22 +41,15 @@ func (atcConfig ATCConfig) ApplyConfigInteraction() bool {
 	return confirm
 }
 
-func (atcConfig ATCConfig) Set(configPath flaghelpers.PathFlag, templateVariables template.Variables, templateVariablesFiles []flaghelpers.PathFlag) error {
-	newConfig := atcConfig.newConfig(configPath, templateVariablesFiles, templateVariables)
-	existingConfig, _, existingConfigVersion, _, err := atcConfig.Client.PipelineConfig(atcConfig.PipelineName)
-	errorMessages := []string{}
+func (atcConfig ATCConfig) Set(configPath flaghelpers.PathFlag, variableFlags VariableFlags) error {
+	newConfig, variables, err := atcConfig.newConfig(configPath, variableFlags)
 	if err != nil {
-		if configError, ok := err.(concourse.PipelineConfigError); ok {
-			errorMessages = configError.ErrorMessages
-		} else {
-			return err
-		}
+		displayhelpers.Failf("%s", err)
 	}
 
-	diff(existingConfig, newConfig)
-
-	if len(errorMessages) > 0 {
-		atcConfig.showPipelineConfigErrors(errorMessages)
+	existingConfigVersion, _, err := atcConfig.diffExisting(newConfig, variables)
+	if err != nil {
+		return err
 	}
 
 	if !atcConfig.ApplyConfigInteraction() {
@@ -77,37 +73,75 @@ func (atcConfig ATCConfig) Set(configPath flaghelpers.PathFlag, templateVariable
 	return nil
 }
 
-func (atcConfig ATCConfig) newConfig(configPath flaghelpers.PathFlag, templateVariablesFiles []flaghelpers.PathFlag, templateVariables template.Variables) atc.Config {
-	configFile, err := ioutil.ReadFile(string(configPath))
+// Diff shows the changes Set would make, and returns whether there are any.
+// Unlike Set it returns every error rather than exiting, so that the caller
+// can tell failing apart from finding changes.
+func (atcConfig ATCConfig) Diff(configPath flaghelpers.PathFlag, variableFlags VariableFlags) (bool, error) {
+	newConfig, variables, err := atcConfig.newConfig(configPath, variableFlags)
 	if err != nil {
-		displayhelpers.FailWithErrorf("could not read config file", err)
+		return false, err
 	}
 
-	var resultVars template.Variables
+	_, changed, err := atcConfig.diffExisting(newConfig, variables)
+	return changed, err
+}
 
-	for _, path := range templateVariablesFiles {
-		fileVars, templateErr := template.LoadVariablesFromFile(string(path))
-		if templateErr != nil {
-			displayhelpers.FailWithErrorf("failed to load variables from file (%s)", templateErr, string(path))
+func (atcConfig ATCConfig) diffExisting(newConfig atc.Config, variables TemplateVariables) (string, bool, error) {
+	existingConfig, _, existingConfigVersion, _, err := atcConfig.Client.PipelineConfig(atcConfig.PipelineName)
+	errorMessages := []string{}
+	if err != nil {
+		if configError, ok := err.(concourse.PipelineConfigError); ok {
+			errorMessages = configError.ErrorMessages
+		} else {
+			return "", false, err
 		}
+	}
+
+	redactor := Redactor{}
+	if !atcConfig.ShowSecrets {
+		redactor = NewRedactor(atcConfig.SecretKeyPatterns, variables.Provided)
+	}
+
+	changed := diff(existingConfig, newConfig, redactor)
 
-		resultVars = resultVars.Merge(fileVars)
+	if len(errorMessages) > 0 {
+		atcConfig.showPipelineConfigErrors(errorMessages)
 	}
 
-	resultVars = resultVars.Merge(templateVariables)
+	return existingConfigVersion, changed, nil
+}
+
+func (atcConfig ATCConfig) newConfig(configPath flaghelpers.PathFlag, variableFlags VariableFlags) (atc.Config, TemplateVariables, error) {
+	configFile, err := ioutil.ReadFile(string(configPath))
+	if err != nil {
+		return atc.Config{}, TemplateVariables{}, fmt.Errorf("could not read config file: %s", err)
+	}
 
-	configFile, err = template.Evaluate(configFile, resultVars)
+	variables, err := ResolveVariables(configFile, variableFlags)
 	if err != nil {
-		displayhelpers.FailWithErrorf("failed to evaluate variables into template", err)
+		return atc.Config{}, TemplateVariables{}, fmt.Errorf("failed to resolve template variables: %s", err)
+	}
+
+	if missing := variables.Missing(); len(missing) > 0 {
+		return atc.Config{}, TemplateVariables{}, fmt.Errorf("missing template variables: %s", strings.Join(missing, ", "))
+	}
+
+	if len(variables.Unused) > 0 {
+		atcConfig.showUnusedVariables(variables.Unused)
+	}
+
+	configFile, err = template.Evaluate(configFile, variables.Values)
+	if err != nil {
+		return atc.Config{}, TemplateVariables{}, fmt.Errorf("failed to evaluate variables into template: %s", err)
 	}
 
 	var newConfig atc.Config
 	err = yaml.Unmarshal(configFile, &newConfig)
 	if err != nil {
-		displayhelpers.FailWithErrorf("failed to parse configuration file", err)
+		return atc.Config{}, TemplateVariables{}, fmt.Errorf("failed to parse configuration file: %s", err)
 	}
 
-	return newConfig
+	return newConfig, variables, nil
 }
 
 func (atcConfig ATCConfig) showPipelineConfigErrors(errorMessages []string) {
@@ -122,6 +156,17 @@ func (atcConfig ATCConfig) showPipelineConfigErrors(errorMessages []string) {
 	fmt.Fprintln(os.Stderr, "")
 }
 
+func (atcConfig ATCConfig) showUnusedVariables(unused []string) {
+	fmt.Fprintln(os.Stderr, "")
+	displayhelpers.PrintWarningHeader()
+
+	for _, name := range unused {
+		fmt.Fprintf(os.Stderr, "  - variable `%s` is provided but never used\n", name)
+	}
+
+	fmt.Fprintln(os.Stderr, "")
+}
+
 func (atcConfig ATCConfig) showWarnings(warnings []concourse.ConfigWarning) {
 	fmt.Fprintln(os.Stderr, "")
 	displayhelpers.PrintDeprecationWarningHeader()
@@ -160,7 +205,9 @@ func (atcConfig ATCConfig) showHelpfulMessage(created bool, updated bool) {
 	}
 }
 
-func diff(existingConfig atc.Config, newConfig atc.Config) {
+// diff prints the differences between the configs, and returns whether
+// there were any.
+func diff(existingConfig atc.Config, newConfig atc.Config, redactor Redactor) bool {
 	indent := gexec.NewPrefixedWriter("  ", os.Stdout)
 
 	groupDiffs := diffIndices(GroupIndex(existingConfig.Groups), GroupIndex(newConfig.Groups))
@@ -168,7 +215,7 @@ func diff(existingConfig atc.Config, newConfig atc.Config) {
 		fmt.Println("groups:")
 
 		for _, diff := range groupDiffs {
-			diff.Render(indent, "group")
+			diff.Render(indent, "group", redactor)
 		}
 	}
 
@@ -177,7 +224,7 @@ func diff(existingConfig atc.Config, newConfig atc.Config) {
 		fmt.Println("resources:")
 
 		for _, diff := range resourceDiffs {
-			diff.Render(indent, "resource")
+			diff.Render(indent, "resource", redactor)
 		}
 	}
 
@@ -186,7 +233,7 @@ func diff(existingConfig atc.Config, newConfig atc.Config) {
 		fmt.Println("resource types:")
 
 		for _, diff := range resourceTypeDiffs {
-			diff.Render(indent, "resource type")
+			diff.Render(indent, "resource type", redactor)
 		}
 	}
 
@@ -195,7 +242,9 @@ func diff(existingConfig atc.Config, newConfig atc.Config) {
 		fmt.Println("jobs:")
 
 		for _, diff := range jobDiffs {
-			diff.Render(indent, "job")
+			diff.Render(indent, "job", redactor)
 		}
 	}
+
+	return len(groupDiffs) > 0 || len(resourceDiffs) > 0 || len(resourceTypeDiffs) > 0 || len(jobDiffs) > 0
 }
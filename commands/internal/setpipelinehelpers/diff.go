@@ -30,26 +30,26 @@ func name(v interface{}) string {
 	return reflect.ValueOf(v).FieldByName("Name").String()
 }
 
-func (diff Diff) Render(to io.Writer, label string) {
+func (diff Diff) Render(to io.Writer, label string, redactor Redactor) {
 	indent := gexec.NewPrefixedWriter("  ", to)
 
 	if diff.Before != nil && diff.After != nil {
 		fmt.Fprintf(to, ansi.Color("%s %s has changed:", "yellow")+"\n", label, name(diff.Before))
 
-		payloadA, _ := yaml.Marshal(diff.Before)
-		payloadB, _ := yaml.Marshal(diff.After)
+		payloadA, _ := redactor.Marshal(diff.Before)
+		payloadB, _ := redactor.Marshal(diff.After)
 
 		renderDiff(indent, string(payloadA), string(payloadB))
 	} else if diff.Before != nil {
 		fmt.Fprintf(to, ansi.Color("%s %s has been removed:", "yellow")+"\n", label, name(diff.Before))
 
-		payloadA, _ := yaml.Marshal(diff.Before)
+		payloadA, _ := redactor.Marshal(diff.Before)
 
 		renderDiff(indent, string(payloadA), "")
 	} else {
 		fmt.Fprintf(to, ansi.Color("%s %s has been added:", "yellow")+"\n", label, name(diff.After))
 
-		payloadB, _ := yaml.Marshal(diff.After)
+		payloadB, _ := redactor.Marshal(diff.After)
 
 		renderDiff(indent, "", string(payloadB))
 	}
@@ -0,0 +1,175 @@
+package setpipelinehelpers
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/concourse/fly/template"
+	"gopkg.in/yaml.v2"
+)
+
+const redactedValue = "((redacted))"
+
+// DefaultSecretKeyPatterns match the config keys whose values are always
+// masked in diffs, even when they are not given by variables.
+var DefaultSecretKeyPatterns = []string{"*password*", "*private_key*", "*secret*", "*token*"}
+
+// Redactor masks secrets in the config shown in diffs: values under keys
+// matching any of the patterns, and any of the given values wherever they
+// appear, including within longer strings.
+type Redactor struct {
+	KeyPatterns []string
+
+	// Values are masked longest first, so that a secret containing another is
+	// masked as a whole
+	Values []string
+}
+
+// minSecretLength keeps short values such as "true" or "1" from masking every
+// field which happens to contain the same thing.
+const minSecretLength = 4
+
+// NewRedactor masks the values of all of the given variables, as any of them
+// may be a secret, along with the values of config keys matching the
+// patterns.
+func NewRedactor(keyPatterns []string, variables template.Variables) Redactor {
+	seen := map[string]bool{}
+	for _, value := range variables {
+		addSecretValues(seen, value)
+	}
+
+	values := []string{}
+	for value := range seen {
+		values = append(values, value)
+	}
+
+	sort.Sort(byLength(values))
+
+	return Redactor{
+		KeyPatterns: keyPatterns,
+		Values:      values,
+	}
+}
+
+// Marshal renders the object as YAML with its secrets masked.
+func (redactor Redactor) Marshal(object interface{}) ([]byte, error) {
+	if len(redactor.KeyPatterns) == 0 && len(redactor.Values) == 0 {
+		return yaml.Marshal(object)
+	}
+
+	payload, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	// MapSlice keeps the order of the fields
+	var generic yaml.MapSlice
+	err = yaml.Unmarshal(payload, &generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(redactor.redact(generic))
+}
+
+func (redactor Redactor) redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		redacted := yaml.MapSlice{}
+
+		for _, item := range v {
+			if redactor.isSecretKey(item.Key) {
+				item.Value = redactedValue
+			} else {
+				item.Value = redactor.redact(item.Value)
+			}
+
+			redacted = append(redacted, item)
+		}
+
+		return redacted
+
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, subValue := range v {
+			redacted[i] = redactor.redact(subValue)
+		}
+
+		return redacted
+
+	case string:
+		return redactor.redactString(v)
+
+	case nil, bool:
+		return value
+
+	default:
+		// numbers and booleans may come from variables too
+		if redactor.redactString(fmt.Sprint(v)) == redactedValue {
+			return redactedValue
+		}
+
+		return value
+	}
+}
+
+func (redactor Redactor) redactString(value string) string {
+	for _, secret := range redactor.Values {
+		value = strings.Replace(value, secret, redactedValue, -1)
+	}
+
+	return value
+}
+
+func (redactor Redactor) isSecretKey(key interface{}) bool {
+	name, ok := key.(string)
+	if !ok {
+		return false
+	}
+
+	for _, pattern := range redactor.KeyPatterns {
+		matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+		if err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func addSecretValues(values map[string]bool, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, subValue := range v {
+			addSecretValues(values, subValue)
+		}
+
+	case []interface{}:
+		for _, subValue := range v {
+			addSecretValues(values, subValue)
+		}
+
+	case nil, bool:
+
+	default:
+		secret := fmt.Sprint(v)
+		if len(secret) >= minSecretLength {
+			values[secret] = true
+		}
+	}
+}
+
+type byLength []string
+
+func (values byLength) Len() int      { return len(values) }
+func (values byLength) Swap(i, j int) { values[i], values[j] = values[j], values[i] }
+
+func (values byLength) Less(i, j int) bool {
+	if len(values[i]) != len(values[j]) {
+		return len(values[i]) > len(values[j])
+	}
+
+	return values[i] < values[j]
+}
@@ -0,0 +1,194 @@
+package setpipelinehelpers
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/template"
+)
+
+const (
+	SourceFlag    = "flag"
+	SourceDefault = "default"
+	SourceMissing = "missing"
+
+	// SourceLeftInPlace is where an unset ((var)) comes from, as it is left
+	// in the pipeline for a credential manager to fill in.
+	SourceLeftInPlace = "left in place"
+)
+
+// VariableUsage is a variable referenced by a pipeline template, along with
+// where its value comes from: a flag, a variables file, a default, or
+// nowhere.
+type VariableUsage struct {
+	Name   string
+	Source string
+}
+
+// VariableFlags are the ways variables can be given to a pipeline template,
+// in increasing order of precedence.
+type VariableFlags struct {
+	Files       []flaghelpers.PathFlag
+	Commands    []string
+	EnvPrefixes []string
+	Variables   template.Variables
+
+	// Passphrase decrypts the variables files that are encrypted.
+	Passphrase []byte
+}
+
+// TemplateVariables are the variables available to a pipeline template.
+type TemplateVariables struct {
+	Values template.Variables
+
+	// Provided are the variables given by the flags, excluding defaults.
+	Provided template.Variables
+
+	Usages []VariableUsage
+	Unused []string
+}
+
+func (variables TemplateVariables) Missing() []string {
+	missing := []string{}
+	for _, usage := range variables.Usages {
+		if usage.Source == SourceMissing {
+			missing = append(missing, usage.Name)
+		}
+	}
+
+	return missing
+}
+
+// ResolveVariables combines the defaults declared by the template with the
+// variables given by the flags.
+func ResolveVariables(configFile []byte, flags VariableFlags) (TemplateVariables, error) {
+	defaults, required, err := template.ParseHeader(configFile)
+	if err != nil {
+		return TemplateVariables{}, err
+	}
+
+	values := template.Variables{}.Merge(defaults)
+
+	sources := map[string]string{}
+	for name := range defaults {
+		sources[name] = SourceDefault
+	}
+
+	provided := map[string]bool{}
+	providedValues := template.Variables{}
+
+	provide := func(variables template.Variables, source string) {
+		values = values.Merge(variables)
+		providedValues = providedValues.Merge(variables)
+
+		for name := range variables {
+			sources[name] = source
+			provided[name] = true
+		}
+	}
+
+	for _, path := range flags.Files {
+		fileVars, err := template.LoadVariablesFromFile(string(path), flags.Passphrase)
+		if err == template.ErrNoPassphrase {
+			return TemplateVariables{}, fmt.Errorf("failed to load variables from file (%s): %s; use --vars-key-file, or set $FLY_VARS_PASSPHRASE or $FLY_VARS_KEY_FILE", string(path), err)
+		}
+
+		if err != nil {
+			return TemplateVariables{}, fmt.Errorf("failed to load variables from file (%s): %s", string(path), err)
+		}
+
+		provide(fileVars, "file "+string(path))
+	}
+
+	for _, command := range flags.Commands {
+		commandVars, err := template.LoadVariablesFromCommand(command)
+		if err != nil {
+			return TemplateVariables{}, fmt.Errorf("failed to load variables from command (%s): %s", command, err)
+		}
+
+		provide(commandVars, "exec "+command)
+	}
+
+	// the environment sets each variable under two names, only one of which
+	// is expected to be used
+	envAliases := map[string]string{}
+
+	for _, prefix := range flags.EnvPrefixes {
+		envVars := template.LoadVariablesFromEnv(prefix, os.Environ())
+
+		for name := range envVars {
+			if alias := template.EnvVariableAlias(name); alias != name {
+				envAliases[name] = alias
+				envAliases[alias] = name
+			}
+		}
+
+		provide(envVars, "env "+prefix)
+	}
+
+	provide(flags.Variables, SourceFlag)
+
+	result := TemplateVariables{
+		Values:   values,
+		Provided: providedValues,
+		Usages:   []VariableUsage{},
+		Unused:   []string{},
+	}
+
+	referenced := map[string]bool{}
+
+	for _, reference := range template.References(configFile) {
+		referenced[reference.Variable()] = true
+
+		usage := VariableUsage{Name: reference.Name}
+
+		if _, found := values.Lookup(reference.Name); found {
+			usage.Source = sources[reference.Variable()]
+		} else if reference.HasDefault {
+			usage.Source = SourceDefault
+		} else if reference.Parenthesized {
+			usage.Source = SourceLeftInPlace
+		} else {
+			usage.Source = SourceMissing
+		}
+
+		result.Usages = append(result.Usages, usage)
+	}
+
+	for _, name := range required {
+		if referenced[name] {
+			continue
+		}
+
+		referenced[name] = true
+
+		usage := VariableUsage{Name: name, Source: SourceMissing}
+		if provided[name] {
+			usage.Source = sources[name]
+		}
+
+		result.Usages = append(result.Usages, usage)
+	}
+
+	for name := range provided {
+		if referenced[name] {
+			continue
+		}
+
+		if alias, found := envAliases[name]; found {
+			// report the pair once, by the name it was given in
+			if referenced[alias] || strings.Contains(name, "-") {
+				continue
+			}
+		}
+
+		result.Unused = append(result.Unused, name)
+	}
+
+	sort.Strings(result.Unused)
+
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package setpipelinehelpers_test
+
+import (
+	"os"
+
+	. "github.com/concourse/fly/commands/internal/setpipelinehelpers"
+
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/template"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResolveVariables", func() {
+	var configFile []byte
+
+	BeforeEach(func() {
+		configFile = []byte(`# vars:
+#   tag: latest
+#   branch: master
+#   github-token:
+#   private-key:
+
+resources:
+- name: some-resource
+  type: git
+  source:
+    uri: https://((host:-example.com))/repo.git
+    branch: {{branch}}
+    password: {{db.password}}
+    tag: {{tag}}
+    token: {{github-token}}
+    name: {{name}}
+    secret: ((vault-secret))
+`)
+	})
+
+	It("determines where each referenced variable comes from", func() {
+		variables, err := ResolveVariables(configFile, VariableFlags{
+			Files:     []flaghelpers.PathFlag{"fixtures/vars.yml"},
+			Variables: template.Variables{"name": "from-flag", "github-token": "from-flag", "unused-from-flag": "x"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(variables.Usages).To(Equal([]VariableUsage{
+			{Name: "host", Source: SourceDefault},
+			{Name: "branch", Source: "file fixtures/vars.yml"},
+			{Name: "db.password", Source: "file fixtures/vars.yml"},
+			{Name: "tag", Source: SourceDefault},
+			{Name: "github-token", Source: SourceFlag},
+			{Name: "name", Source: SourceFlag},
+			{Name: "vault-secret", Source: SourceLeftInPlace},
+			{Name: "private-key", Source: SourceMissing},
+		}))
+
+		Expect(variables.Missing()).To(Equal([]string{"private-key"}))
+		Expect(variables.Unused).To(Equal([]string{"unused-from-file", "unused-from-flag"}))
+	})
+
+	It("gives flags precedence over files, and files over defaults", func() {
+		variables, err := ResolveVariables(configFile, VariableFlags{
+			Files:     []flaghelpers.PathFlag{"fixtures/vars.yml"},
+			Variables: template.Variables{"branch": "from-flag"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(variables.Values["branch"]).To(Equal("from-flag"))
+		Expect(variables.Values["tag"]).To(Equal("latest"))
+		Expect(variables.Values["db"]).To(Equal(map[string]interface{}{"password": "from-file"}))
+	})
+
+	It("loads variables from commands and the environment", func() {
+		os.Setenv("FLY_TEST_VAR_BRANCH", "from-env")
+		defer os.Unsetenv("FLY_TEST_VAR_BRANCH")
+
+		variables, err := ResolveVariables(configFile, VariableFlags{
+			Files:       []flaghelpers.PathFlag{"fixtures/vars.yml"},
+			Commands:    []string{"echo 'name: from-exec'"},
+			EnvPrefixes: []string{"FLY_TEST_VAR_"},
+			Variables:   template.Variables{"github-token": "from-flag"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(variables.Values["name"]).To(Equal("from-exec"))
+		Expect(variables.Values["branch"]).To(Equal("from-env"))
+		Expect(variables.Usages).To(ContainElement(VariableUsage{Name: "name", Source: "exec echo 'name: from-exec'"}))
+		Expect(variables.Usages).To(ContainElement(VariableUsage{Name: "branch", Source: "env FLY_TEST_VAR_"}))
+	})
+
+	It("accepts variables from the environment by their names with underscores or dashes", func() {
+		os.Setenv("FLY_TEST_VAR_GITHUB_TOKEN", "from-env")
+		defer os.Unsetenv("FLY_TEST_VAR_GITHUB_TOKEN")
+
+		os.Setenv("FLY_TEST_VAR_UNUSED_TOKEN", "from-env")
+		defer os.Unsetenv("FLY_TEST_VAR_UNUSED_TOKEN")
+
+		variables, err := ResolveVariables(configFile, VariableFlags{
+			EnvPrefixes: []string{"FLY_TEST_VAR_"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(variables.Values["github-token"]).To(Equal("from-env"))
+		Expect(variables.Values["github_token"]).To(Equal("from-env"))
+		Expect(variables.Usages).To(ContainElement(VariableUsage{Name: "github-token", Source: "env FLY_TEST_VAR_"}))
+		Expect(variables.Unused).To(Equal([]string{"unused_token"}))
+	})
+
+	It("returns an error when a command fails", func() {
+		_, err := ResolveVariables(configFile, VariableFlags{
+			Commands: []string{"exit 1"},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error when a variables file cannot be loaded", func() {
+		_, err := ResolveVariables(configFile, VariableFlags{
+			Files: []flaghelpers.PathFlag{"fixtures/missing.yml"},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/concourse/fly/commands/internal/executehelpers"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/fly/rc"
+)
+
+type RerunBuildCommand struct {
+	Job   flaghelpers.JobFlag `short:"j" long:"job"   required:"true" value-name:"PIPELINE/JOB" description:"Name of the job the build belongs to"`
+	Build string              `short:"b" long:"build" required:"true"                           description:"Name of the job build to rerun"`
+	Watch bool                `short:"w" long:"watch"                                           description:"Start watching the new build output"`
+}
+
+func (command *RerunBuildCommand) Execute(args []string) error {
+	client, err := rc.TargetClient(Fly.Target)
+	if err != nil {
+		return err
+	}
+	err = rc.ValidateClient(client, Fly.Target, false)
+	if err != nil {
+		return err
+	}
+
+	build, exists, err := client.JobBuild(command.Job.PipelineName, command.Job.JobName, command.Build)
+	if err != nil {
+		return fmt.Errorf("failed to get job build: %s", err)
+	}
+
+	if !exists {
+		return errors.New("job build does not exist")
+	}
+
+	config, _, _, found, err := client.PipelineConfig(command.Job.PipelineName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return errors.New("pipeline does not exist")
+	}
+
+	jobConfig, found := config.Jobs.Lookup(command.Job.JobName)
+	if !found {
+		return errors.New("job does not exist")
+	}
+
+	inputs, err := executehelpers.FetchInputsFromBuild(client, config, jobConfig, build)
+	if err != nil {
+		return err
+	}
+
+	rerun, err := executehelpers.CreateRerunBuild(client, inputs, jobConfig, config.ResourceTypes, Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("started rerun of %s/%s #%s as build %d\n", command.Job.PipelineName, command.Job.JobName, build.Name, rerun.ID)
+
+	if !command.Watch {
+		return nil
+	}
+
+	terminate := make(chan os.Signal, 1)
+
+	go abortOnSignal(client, terminate, rerun)
+
+	signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+
+	eventSource, err := client.BuildEvents(fmt.Sprintf("%d", rerun.ID))
+	if err != nil {
+		return err
+	}
+
+	exitCode := eventstream.Render(os.Stdout, eventSource)
+
+	eventSource.Close()
+
+	os.Exit(exitCode)
+
+	return nil
+}
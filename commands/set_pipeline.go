@@ -1,6 +1,9 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/concourse/atc/web"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/commands/internal/setpipelinehelpers"
@@ -10,40 +13,114 @@ import (
 )
 
 type SetPipelineCommand struct {
-	Pipeline        string                         `short:"p"  long:"pipeline" required:"true"      description:"Pipeline to configure"`
-	Config          flaghelpers.PathFlag           `short:"c"  long:"config" required:"true"        description:"Pipeline configuration file"`
-	Var             []flaghelpers.VariablePairFlag `short:"v"  long:"var" value-name:"[SECRET=KEY]" description:"Variable flag that can be used for filling in template values in configuration"`
-	VarsFrom        []flaghelpers.PathFlag         `short:"l"  long:"load-vars-from"                description:"Variable flag that can be used for filling in template values in configuration from a YAML file"`
-	SkipInteractive bool                           `short:"n"  long:"non-interactive"               description:"Skips interactions, uses default values"`
+	Pipeline        string                             `short:"p"  long:"pipeline" required:"true"            description:"Pipeline to configure"`
+	Config          flaghelpers.PathFlag               `short:"c"  long:"config" required:"true"              description:"Pipeline configuration file"`
+	Var             []flaghelpers.VariablePairFlag     `short:"v"  long:"var" value-name:"[SECRET=KEY]"       description:"Variable flag that can be used for filling in template values in configuration"`
+	YAMLVar         []flaghelpers.YAMLVariablePairFlag `short:"y"  long:"yaml-var" value-name:"[NAME=YAML]"   description:"Variable flag that can be used for filling in template values in configuration with a YAML value"`
+	VarsFrom        []flaghelpers.PathFlag             `short:"l"  long:"load-vars-from"                      description:"Variable flag that can be used for filling in template values in configuration from a YAML file"`
+	VarsFromExec    []string                           `           long:"vars-from-exec" value-name:"COMMAND" description:"Variable flag that can be used for filling in template values in configuration from the YAML printed by a command"`
+	VarsFromEnv     []string                           `           long:"vars-from-env" value-name:"PREFIX"   description:"Variable flag that can be used for filling in template values in configuration from environment variables starting with PREFIX (PREFIX_SOME_VAR sets both some_var and some-var)"`
+	VarsKeyFile     flaghelpers.PathFlag               `           long:"vars-key-file"                       description:"File containing the passphrase for encrypted variables files (defaults to $FLY_VARS_PASSPHRASE, then $FLY_VARS_KEY_FILE)"`
+	SecretKeys      []string                           `           long:"secret-key" value-name:"PATTERN"     description:"Mask the values of config keys matching this pattern in the diff, in addition to *password*, *private_key*, *secret* and *token* (values given by variables are always masked)"`
+	ShowSecrets     bool                               `           long:"show-secrets"                        description:"Show secrets in the diff instead of masking them"`
+	Check           bool                               `           long:"check"                               description:"Show the diff without applying it, exiting 1 if there are changes, 0 if not, and 2 if the diff could not be made"`
+	SkipInteractive bool                               `short:"n"  long:"non-interactive"                     description:"Skips interactions, uses default values"`
 }
 
+// checkErroredExitCode tells failing to check for changes apart from finding
+// some, which exits 1.
+const checkErroredExitCode = 2
+
 func (command *SetPipelineCommand) Execute(args []string) error {
-	configPath := command.Config
-	templateVariablesFiles := command.VarsFrom
-	pipelineName := command.Pipeline
+	if command.Check {
+		changed, err := command.checkForChanges()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(checkErroredExitCode)
+		}
+
+		if changed {
+			os.Exit(1)
+		}
 
-	templateVariables := template.Variables{}
-	for _, v := range command.Var {
-		templateVariables[v.Name] = v.Value
+		fmt.Println("no changes to apply")
+		return nil
 	}
 
-	client, err := rc.TargetClient(Fly.Target)
+	atcConfig, err := command.atcConfig()
 	if err != nil {
 		return err
 	}
-	err = rc.ValidateClient(client, Fly.Target, false)
+
+	variableFlags, err := command.variableFlags()
 	if err != nil {
 		return err
 	}
 
+	return atcConfig.Set(command.Config, variableFlags)
+}
+
+func (command *SetPipelineCommand) checkForChanges() (bool, error) {
+	atcConfig, err := command.atcConfig()
+	if err != nil {
+		return false, err
+	}
+
+	variableFlags, err := command.variableFlags()
+	if err != nil {
+		return false, err
+	}
+
+	return atcConfig.Diff(command.Config, variableFlags)
+}
+
+func (command *SetPipelineCommand) atcConfig() (setpipelinehelpers.ATCConfig, error) {
+	client, err := rc.TargetClient(Fly.Target)
+	if err != nil {
+		return setpipelinehelpers.ATCConfig{}, err
+	}
+	err = rc.ValidateClient(client, Fly.Target, false)
+	if err != nil {
+		return setpipelinehelpers.ATCConfig{}, err
+	}
+
 	webRequestGenerator := rata.NewRequestGenerator(client.URL(), web.Routes)
 
-	atcConfig := setpipelinehelpers.ATCConfig{
-		PipelineName:        pipelineName,
+	return setpipelinehelpers.ATCConfig{
+		PipelineName:        command.Pipeline,
 		WebRequestGenerator: webRequestGenerator,
 		Client:              client,
 		SkipInteraction:     command.SkipInteractive,
+		ShowSecrets:         command.ShowSecrets,
+		SecretKeyPatterns:   append(append([]string{}, setpipelinehelpers.DefaultSecretKeyPatterns...), command.SecretKeys...),
+	}, nil
+}
+
+func (command *SetPipelineCommand) variableFlags() (setpipelinehelpers.VariableFlags, error) {
+	passphrase, err := givenVarsPassphrase(command.VarsKeyFile)
+	if err != nil {
+		return setpipelinehelpers.VariableFlags{}, err
+	}
+
+	return setpipelinehelpers.VariableFlags{
+		Files:       command.VarsFrom,
+		Commands:    command.VarsFromExec,
+		EnvPrefixes: command.VarsFromEnv,
+		Variables:   templateVariables(command.Var, command.YAMLVar),
+		Passphrase:  passphrase,
+	}, nil
+}
+
+func templateVariables(vars []flaghelpers.VariablePairFlag, yamlVars []flaghelpers.YAMLVariablePairFlag) template.Variables {
+	variables := template.Variables{}
+
+	for _, v := range vars {
+		variables[v.Name] = v.Value
+	}
+
+	for _, v := range yamlVars {
+		variables[v.Name] = v.Value
 	}
 
-	return atcConfig.Set(configPath, templateVariables, templateVariablesFiles)
+	return variables
 }
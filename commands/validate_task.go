@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/config"
+)
+
+type ValidateTaskCommand struct {
+	TaskConfig flaghelpers.PathFlag `short:"c" long:"config" required:"true" description:"The task config to validate"`
+	Strict     bool                 `          long:"strict"                 description:"Fail on warnings as well as errors"`
+	JSON       bool                 `short:"j" long:"json"                   description:"Print the results as json"`
+}
+
+type validateTaskResult struct {
+	Valid    bool             `json:"valid"`
+	Problems []config.Problem `json:"problems"`
+}
+
+func (command *ValidateTaskCommand) Execute(args []string) error {
+	configFile, err := config.ReadTaskConfig(string(command.TaskConfig))
+	if err != nil {
+		return err
+	}
+
+	problems := []config.Problem{}
+
+	taskConfig, err := atc.LoadTaskConfig(configFile)
+	if err != nil {
+		problems = append(problems, config.Problem{
+			Severity: config.SeverityError,
+			Check:    "invalid-config",
+			Message:  err.Error(),
+		})
+	} else {
+		err = taskConfig.Validate()
+		if err != nil {
+			problems = append(problems, config.Problem{
+				Severity: config.SeverityError,
+				Check:    "invalid-config",
+				Message:  err.Error(),
+			})
+		}
+
+		problems = append(problems, config.LintTaskConfig(taskConfig)...)
+	}
+
+	result := validateTaskResult{
+		Valid:    true,
+		Problems: problems,
+	}
+
+	for _, problem := range problems {
+		if problem.Severity == config.SeverityError || command.Strict {
+			result.Valid = false
+		}
+	}
+
+	if command.JSON {
+		payload, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(payload))
+	} else {
+		for _, problem := range problems {
+			fmt.Printf("%s: %s (%s)\n", problem.Severity, problem.Message, problem.Check)
+		}
+
+		if len(problems) == 0 {
+			fmt.Println("looks good")
+		}
+	}
+
+	if !result.Valid {
+		os.Exit(1)
+	}
+
+	return nil
+}
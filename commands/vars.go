@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/concourse/fly/commands/internal/displayhelpers"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/commands/internal/setpipelinehelpers"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+)
+
+type VarsCommand struct {
+	Config       flaghelpers.PathFlag               `short:"c"  long:"config" required:"true"              description:"Pipeline configuration file"`
+	Var          []flaghelpers.VariablePairFlag     `short:"v"  long:"var" value-name:"[SECRET=KEY]"       description:"Variable flag that can be used for filling in template values in configuration"`
+	YAMLVar      []flaghelpers.YAMLVariablePairFlag `short:"y"  long:"yaml-var" value-name:"[NAME=YAML]"   description:"Variable flag that can be used for filling in template values in configuration with a YAML value"`
+	VarsFrom     []flaghelpers.PathFlag             `short:"l"  long:"load-vars-from"                      description:"Variable flag that can be used for filling in template values in configuration from a YAML file"`
+	VarsFromExec []string                           `           long:"vars-from-exec" value-name:"COMMAND" description:"Variable flag that can be used for filling in template values in configuration from the YAML printed by a command"`
+	VarsFromEnv  []string                           `           long:"vars-from-env" value-name:"PREFIX"   description:"Variable flag that can be used for filling in template values in configuration from environment variables starting with PREFIX (PREFIX_SOME_VAR sets both some_var and some-var)"`
+	VarsKeyFile  flaghelpers.PathFlag               `           long:"vars-key-file"                       description:"File containing the passphrase for encrypted variables files (defaults to $FLY_VARS_PASSPHRASE, then $FLY_VARS_KEY_FILE)"`
+}
+
+func (command *VarsCommand) Execute([]string) error {
+	configFile, err := ioutil.ReadFile(string(command.Config))
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := givenVarsPassphrase(command.VarsKeyFile)
+	if err != nil {
+		return err
+	}
+
+	variables, err := setpipelinehelpers.ResolveVariables(configFile, setpipelinehelpers.VariableFlags{
+		Files:       command.VarsFrom,
+		Commands:    command.VarsFromExec,
+		EnvPrefixes: command.VarsFromEnv,
+		Variables:   templateVariables(command.Var, command.YAMLVar),
+		Passphrase:  passphrase,
+	})
+	if err != nil {
+		return err
+	}
+
+	table := ui.Table{
+		Headers: ui.TableRow{
+			{Contents: "name", Color: color.New(color.Bold)},
+			{Contents: "source", Color: color.New(color.Bold)},
+		},
+	}
+
+	for _, usage := range variables.Usages {
+		sourceColumn := ui.TableCell{Contents: usage.Source}
+		if usage.Source == setpipelinehelpers.SourceMissing {
+			sourceColumn.Color = ui.FailedColor
+		}
+
+		table.Data = append(table.Data, ui.TableRow{
+			{Contents: usage.Name},
+			sourceColumn,
+		})
+	}
+
+	err = table.Render(os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if len(variables.Unused) > 0 {
+		fmt.Fprintln(os.Stderr, "")
+		displayhelpers.PrintWarningHeader()
+
+		for _, name := range variables.Unused {
+			fmt.Fprintf(os.Stderr, "  - variable `%s` is provided but never used\n", name)
+		}
+	}
+
+	return nil
+}
@@ -3,15 +3,16 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"syscall"
 
 	"github.com/concourse/atc"
 )
 
 func LoadTaskConfig(configPath string, args []string) (atc.TaskConfig, error) {
-	configFile, err := ioutil.ReadFile(configPath)
+	configFile, err := ReadTaskConfig(configPath)
 	if err != nil {
-		return atc.TaskConfig{}, fmt.Errorf("failed to read task config: %s", err)
+		return atc.TaskConfig{}, err
 	}
 
 	config, err := atc.LoadTaskConfig(configFile)
@@ -19,6 +20,21 @@ func LoadTaskConfig(configPath string, args []string) (atc.TaskConfig, error) {
 		return atc.TaskConfig{}, err
 	}
 
+	return OverrideTaskConfig(config, args), nil
+}
+
+func ReadTaskConfig(configPath string) ([]byte, error) {
+	configFile, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task config: %s", err)
+	}
+
+	return configFile, nil
+}
+
+// OverrideTaskConfig appends the given arguments to the task's command and
+// overrides any of its params that are set in the environment.
+func OverrideTaskConfig(config atc.TaskConfig, args []string) atc.TaskConfig {
 	config.Run.Args = append(config.Run.Args, args...)
 
 	for k, _ := range config.Params {
@@ -28,5 +44,36 @@ func LoadTaskConfig(configPath string, args []string) (atc.TaskConfig, error) {
 		}
 	}
 
-	return config, nil
+	return config
+}
+
+// OverrideImage replaces the task's image. A rootfs URI such as
+// docker:///busybox is used as is, anything else is taken to be a Docker
+// image reference.
+func OverrideImage(config atc.TaskConfig, ref string) atc.TaskConfig {
+	if strings.Contains(ref, "://") {
+		config.Image = ref
+		config.ImageResource = nil
+		return config
+	}
+
+	repository, tag := ref, ""
+
+	// a colon before the last slash belongs to the registry's port
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		repository, tag = ref[:i], ref[i+1:]
+	}
+
+	source := atc.Source{"repository": repository}
+	if tag != "" {
+		source["tag"] = tag
+	}
+
+	config.Image = ""
+	config.ImageResource = &atc.ImageResource{
+		Type:   "docker-image",
+		Source: source,
+	}
+
+	return config
 }
@@ -0,0 +1,49 @@
+package config_test
+
+import (
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OverrideImage", func() {
+	var taskConfig atc.TaskConfig
+
+	BeforeEach(func() {
+		taskConfig = atc.TaskConfig{
+			Image: "docker:///ubuntu",
+		}
+	})
+
+	It("uses a Docker image reference as the image resource", func() {
+		overridden := config.OverrideImage(taskConfig, "registry.example.com:5000/some/image:some-tag")
+
+		Expect(overridden.Image).To(BeEmpty())
+		Expect(overridden.ImageResource).To(Equal(&atc.ImageResource{
+			Type: "docker-image",
+			Source: atc.Source{
+				"repository": "registry.example.com:5000/some/image",
+				"tag":        "some-tag",
+			},
+		}))
+	})
+
+	It("leaves out the tag when none is given", func() {
+		overridden := config.OverrideImage(taskConfig, "registry.example.com:5000/some/image")
+
+		Expect(overridden.ImageResource.Source).To(Equal(atc.Source{
+			"repository": "registry.example.com:5000/some/image",
+		}))
+	})
+
+	It("uses a rootfs URI as is", func() {
+		taskConfig.ImageResource = &atc.ImageResource{Type: "docker-image"}
+
+		overridden := config.OverrideImage(taskConfig, "docker:///busybox")
+
+		Expect(overridden.Image).To(Equal("docker:///busybox"))
+		Expect(overridden.ImageResource).To(BeNil())
+	})
+})
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/concourse/atc"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+type Problem struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+}
+
+// LintTaskConfig checks the task for likely mistakes that the ATC would not
+// reject on its own. Those it would reject are left to TaskConfig.Validate.
+func LintTaskConfig(config atc.TaskConfig) []Problem {
+	problems := []Problem{}
+
+	references := append([]string{config.Run.Path, config.Run.Dir}, config.Run.Args...)
+
+	for _, input := range config.Inputs {
+		if !referenced(references, input.Name, inputDir(input)) {
+			problems = append(problems, Problem{
+				Severity: SeverityWarning,
+				Check:    "unreferenced-input",
+				Message:  fmt.Sprintf("input `%s` is not referenced by run.path, run.args or run.dir", input.Name),
+			})
+		}
+	}
+
+	for _, output := range config.Outputs {
+		for _, input := range config.Inputs {
+			if overlaps(outputDir(output), inputDir(input)) {
+				problems = append(problems, Problem{
+					Severity: SeverityWarning,
+					Check:    "overlapping-output",
+					Message:  fmt.Sprintf("output `%s` overlaps input `%s`", output.Name, input.Name),
+				})
+			}
+		}
+	}
+
+	emptyParams := []string{}
+	for name, value := range config.Params {
+		if value == "" {
+			emptyParams = append(emptyParams, name)
+		}
+	}
+
+	sort.Strings(emptyParams)
+
+	for _, name := range emptyParams {
+		problems = append(problems, Problem{
+			Severity: SeverityWarning,
+			Check:    "empty-param",
+			Message:  fmt.Sprintf("param `%s` has an empty default", name),
+		})
+	}
+
+	return problems
+}
+
+func inputDir(input atc.TaskInputConfig) string {
+	if input.Path != "" {
+		return path.Clean(input.Path)
+	}
+
+	return input.Name
+}
+
+func outputDir(output atc.TaskOutputConfig) string {
+	if output.Path != "" {
+		return path.Clean(output.Path)
+	}
+
+	return output.Name
+}
+
+// referenced reports whether any of the names appear in the references as
+// whole path segments, e.g. source in ./source/ci/build or "cd source", but
+// not in sources/ci/build.
+func referenced(references []string, names ...string) bool {
+	for _, name := range names {
+		segment := regexp.MustCompile(`(^|[^\w.-])` + regexp.QuoteMeta(name) + `($|[^\w.-])`)
+
+		for _, reference := range references {
+			if segment.MatchString(reference) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func overlaps(a string, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
@@ -0,0 +1,83 @@
+package config_test
+
+import (
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LintTaskConfig", func() {
+	var taskConfig atc.TaskConfig
+
+	BeforeEach(func() {
+		taskConfig = atc.TaskConfig{
+			Platform: "linux",
+			Inputs: []atc.TaskInputConfig{
+				{Name: "source"},
+			},
+			Outputs: []atc.TaskOutputConfig{
+				{Name: "built"},
+			},
+			Params: map[string]string{
+				"FOO": "foo",
+			},
+			Run: atc.TaskRunConfig{
+				Path: "source/ci/build",
+			},
+		}
+	})
+
+	It("finds no problems with a sound task", func() {
+		Expect(config.LintTaskConfig(taskConfig)).To(BeEmpty())
+	})
+
+	It("leaves a missing run.path to the config's validation", func() {
+		taskConfig.Run.Path = ""
+		taskConfig.Run.Dir = "source"
+
+		Expect(config.LintTaskConfig(taskConfig)).To(BeEmpty())
+	})
+
+	It("reports inputs that are never referenced", func() {
+		taskConfig.Inputs = append(taskConfig.Inputs, atc.TaskInputConfig{Name: "unused"})
+
+		Expect(config.LintTaskConfig(taskConfig)).To(ConsistOf(config.Problem{
+			Severity: config.SeverityWarning,
+			Check:    "unreferenced-input",
+			Message:  "input `unused` is not referenced by run.path, run.args or run.dir",
+		}))
+	})
+
+	It("only counts references to whole path segments", func() {
+		taskConfig.Inputs = append(taskConfig.Inputs, atc.TaskInputConfig{Name: "sour"}, atc.TaskInputConfig{Name: "src"})
+		taskConfig.Run.Args = []string{"-c", "cd src && ./build"}
+
+		Expect(config.LintTaskConfig(taskConfig)).To(ConsistOf(config.Problem{
+			Severity: config.SeverityWarning,
+			Check:    "unreferenced-input",
+			Message:  "input `sour` is not referenced by run.path, run.args or run.dir",
+		}))
+	})
+
+	It("reports outputs that overlap inputs", func() {
+		taskConfig.Outputs = append(taskConfig.Outputs, atc.TaskOutputConfig{Name: "nested", Path: "source/out"})
+
+		Expect(config.LintTaskConfig(taskConfig)).To(ConsistOf(config.Problem{
+			Severity: config.SeverityWarning,
+			Check:    "overlapping-output",
+			Message:  "output `nested` overlaps input `source`",
+		}))
+	})
+
+	It("reports params with empty defaults", func() {
+		taskConfig.Params["SECRET"] = ""
+
+		Expect(config.LintTaskConfig(taskConfig)).To(ConsistOf(config.Problem{
+			Severity: config.SeverityWarning,
+			Check:    "empty-param",
+			Message:  "param `SECRET` has an empty default",
+		}))
+	})
+})
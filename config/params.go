@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/concourse/atc"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadParamsFile reads task params from a YAML file, or from a dotenv file
+// of KEY=VALUE lines if it is not named .yml or .yaml.
+func LoadParamsFile(path string) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read params file: %s", err)
+	}
+
+	switch filepath.Ext(path) {
+	case ".yml", ".yaml":
+		return parseYAMLParams(path, contents)
+	default:
+		return parseDotenvParams(path, contents)
+	}
+}
+
+func parseYAMLParams(path string, contents []byte) (map[string]string, error) {
+	var values map[string]interface{}
+	err := yaml.Unmarshal(contents, &values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+	}
+
+	params := map[string]string{}
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			params[key] = v
+		case nil:
+			params[key] = ""
+		case bool, int, int64, uint64, float64:
+			params[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("param `%s` in %s must be a string, number or boolean", key, path)
+		}
+	}
+
+	return params, nil
+}
+
+func parseDotenvParams(path string, contents []byte) (map[string]string, error) {
+	params := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid line %d in %s (must be KEY=VALUE)", lineNumber, path)
+		}
+
+		key := strings.TrimSpace(kv[0])
+		value := strings.TrimSpace(kv[1])
+
+		if len(value) >= 2 {
+			switch {
+			case value[0] == '"' && value[len(value)-1] == '"':
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value on line %d in %s: %s", lineNumber, path, err)
+				}
+
+				value = unquoted
+			case value[0] == '\'' && value[len(value)-1] == '\'':
+				value = value[1 : len(value)-1]
+			}
+		}
+
+		params[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// ApplyParams sets the params on the task, returning the names of those that
+// the task does not declare.
+func ApplyParams(config atc.TaskConfig, params map[string]string) (atc.TaskConfig, []string) {
+	if len(params) == 0 {
+		return config, nil
+	}
+
+	merged := map[string]string{}
+	for key, value := range config.Params {
+		merged[key] = value
+	}
+
+	undeclared := []string{}
+	for key, value := range params {
+		if _, declared := config.Params[key]; !declared {
+			undeclared = append(undeclared, key)
+		}
+
+		merged[key] = value
+	}
+
+	sort.Strings(undeclared)
+
+	config.Params = merged
+
+	return config, undeclared
+}
@@ -0,0 +1,105 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Params", func() {
+	var tmpdir string
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "params")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	writeFile := func(name string, contents string) string {
+		path := filepath.Join(tmpdir, name)
+		err := ioutil.WriteFile(path, []byte(contents), 0644)
+		Expect(err).NotTo(HaveOccurred())
+		return path
+	}
+
+	Describe("LoadParamsFile", func() {
+		It("reads dotenv files", func() {
+			path := writeFile("params.env", `
+# a comment
+FOO=bar
+export BAZ = "some \"quoted\" value"
+QUX='single quoted'
+EMPTY=
+`)
+
+			params, err := config.LoadParamsFile(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(params).To(Equal(map[string]string{
+				"FOO":   "bar",
+				"BAZ":   `some "quoted" value`,
+				"QUX":   "single quoted",
+				"EMPTY": "",
+			}))
+		})
+
+		It("rejects dotenv lines without a value", func() {
+			path := writeFile("params.env", "FOO=bar\nBAZ\n")
+
+			_, err := config.LoadParamsFile(path)
+			Expect(err).To(MatchError("invalid line 2 in " + path + " (must be KEY=VALUE)"))
+		})
+
+		It("reads YAML files", func() {
+			path := writeFile("params.yml", `
+FOO: bar
+COUNT: 3
+ENABLED: true
+`)
+
+			params, err := config.LoadParamsFile(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(params).To(Equal(map[string]string{
+				"FOO":     "bar",
+				"COUNT":   "3",
+				"ENABLED": "true",
+			}))
+		})
+	})
+
+	Describe("ApplyParams", func() {
+		It("overrides the task's params and reports undeclared ones", func() {
+			taskConfig := atc.TaskConfig{
+				Params: map[string]string{
+					"FOO": "foo",
+					"BAR": "bar",
+				},
+			}
+
+			applied, undeclared := config.ApplyParams(taskConfig, map[string]string{
+				"FOO":   "overridden",
+				"BOGUS": "bogus",
+			})
+
+			Expect(applied.Params).To(Equal(map[string]string{
+				"FOO":   "overridden",
+				"BAR":   "bar",
+				"BOGUS": "bogus",
+			}))
+			Expect(undeclared).To(Equal([]string{"BOGUS"}))
+
+			Expect(taskConfig.Params["FOO"]).To(Equal("foo"))
+		})
+	})
+})
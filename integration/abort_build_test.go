@@ -1,6 +1,8 @@
 package integration_test
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"os/exec"
 
@@ -40,29 +42,40 @@ var _ = Describe("AbortBuild", func() {
 		)
 	})
 
-	Context("when the job name is not specified", func() {
-		It("asks the user to specifiy a job name", func() {
-			flyCmd := exec.Command(flyPath, "-t", targetName, "abort-build", "-b", "some-build-name")
+	Context("when neither a build nor --all-running is specified", func() {
+		It("asks the user to specify a build", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "abort-build", "-j", "some-pipeline-name/some-job-name")
 
 			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
 			Expect(err).NotTo(HaveOccurred())
 
 			Eventually(sess).Should(gexec.Exit(1))
 
-			Expect(sess.Err).To(gbytes.Say("error: the required flag `" + osFlag("j", "job") + "' was not specified"))
+			Expect(sess.Err).To(gbytes.Say("error: a build must be specified with --build, or use --all-running"))
 		})
 	})
 
-	Context("when the build name is not specified", func() {
-		It("asks the user to specifiy a build name", func() {
-			flyCmd := exec.Command(flyPath, "-t", targetName, "abort-build", "-j", "some-pipeline-name/some-job-name")
+	Context("when only a build ID is specified", func() {
+		BeforeEach(func() {
+			atcServer.SetHandler(3, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v1/builds/23"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, atc.Build{ID: 23}),
+			))
+		})
 
-			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
-			Expect(err).NotTo(HaveOccurred())
+		It("aborts the build with that ID", func() {
+			Expect(func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "abort-build", "-b", "23")
 
-			Eventually(sess).Should(gexec.Exit(1))
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
 
-			Expect(sess.Err).To(gbytes.Say("error: the required flag `" + osFlag("b", "build") + "' was not specified"))
+				Expect(sess.Out).To(gbytes.Say("build successfully aborted"))
+			}).To(Change(func() int {
+				return len(atcServer.ReceivedRequests())
+			}).By(3))
 		})
 	})
 
@@ -102,7 +115,7 @@ var _ = Describe("AbortBuild", func() {
 
 				Eventually(sess).Should(gexec.Exit(1))
 
-				Expect(sess.Err).To(gbytes.Say("error: failed to get job build"))
+				Expect(sess.Err).To(gbytes.Say("error: failed to get job build: "))
 			}).To(Change(func() int {
 				return len(atcServer.ReceivedRequests())
 			}).By(2))
@@ -153,10 +166,224 @@ var _ = Describe("AbortBuild", func() {
 
 				Eventually(sess).Should(gexec.Exit(1))
 
-				Expect(sess.Err).To(gbytes.Say("error: failed to abort build"))
+				Expect(sess.Err).To(gbytes.Say("error: failed to abort build: "))
 			}).To(Change(func() int {
 				return len(atcServer.ReceivedRequests())
 			}).By(3))
 		})
 	})
+
+	Context("when aborting all running builds of a job", func() {
+		BeforeEach(func() {
+			atcServer.SetHandler(3, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v1/pipelines/my-pipeline/jobs/my-job/builds", "limit=100"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Build{
+					{ID: 25, Name: "44", Status: "pending", PipelineName: "my-pipeline", JobName: "my-job"},
+					{ID: 24, Name: "43", Status: "started", PipelineName: "my-pipeline", JobName: "my-job"},
+					{ID: 23, Name: "42", Status: "succeeded", PipelineName: "my-pipeline", JobName: "my-job"},
+				}),
+			))
+
+			atcServer.SetHandler(4, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/api/v1/builds/25/abort"),
+				ghttp.RespondWith(http.StatusNoContent, ""),
+			))
+
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/24/abort"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+		})
+
+		It("lists and aborts only the running builds", func() {
+			Expect(func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "abort-build", "-j", "my-pipeline/my-job", "--all-running", "-n")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				Expect(sess.Out).To(gbytes.Say("the following builds will be aborted:"))
+				Expect(sess.Out).To(gbytes.Say(`my-pipeline/my-job #44 \(id 25, pending\)`))
+				Expect(sess.Out).To(gbytes.Say(`my-pipeline/my-job #43 \(id 24, started\)`))
+				Expect(sess.Out).To(gbytes.Say("aborted my-pipeline/my-job #44"))
+				Expect(sess.Out).To(gbytes.Say("aborted my-pipeline/my-job #43"))
+			}).To(Change(func() int {
+				return len(atcServer.ReceivedRequests())
+			}).By(4))
+		})
+
+		Context("when the builds span several pages", func() {
+			BeforeEach(func() {
+				atcServer.SetHandler(3, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/my-pipeline/jobs/my-job/builds", "limit=100"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Build{
+						{ID: 25, Name: "44", Status: "started", PipelineName: "my-pipeline", JobName: "my-job"},
+						{ID: 24, Name: "43", Status: "succeeded", PipelineName: "my-pipeline", JobName: "my-job"},
+					}, http.Header{
+						"Link": []string{`<` + atcServer.URL() + `/api/v1/pipelines/my-pipeline/jobs/my-job/builds?until=24&limit=100>; rel="next"`},
+					}),
+				))
+
+				atcServer.SetHandler(4, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/my-pipeline/jobs/my-job/builds", "until=24&limit=100"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Build{
+						{ID: 23, Name: "42", Status: "succeeded", PipelineName: "my-pipeline", JobName: "my-job"},
+						{ID: 22, Name: "41", Status: "failed", PipelineName: "my-pipeline", JobName: "my-job"},
+					}, http.Header{
+						"Link": []string{`<` + atcServer.URL() + `/api/v1/pipelines/my-pipeline/jobs/my-job/builds?until=22&limit=100>; rel="next"`},
+					}),
+				))
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/pipelines/my-pipeline/jobs/my-job/builds", "until=22&limit=100"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Build{
+							{ID: 21, Name: "40", Status: "started", PipelineName: "my-pipeline", JobName: "my-job"},
+						}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/api/v1/builds/25/abort"),
+						ghttp.RespondWith(http.StatusNoContent, ""),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/api/v1/builds/21/abort"),
+						ghttp.RespondWith(http.StatusNoContent, ""),
+					),
+				)
+			})
+
+			It("aborts the running builds on every page", func() {
+				Expect(func() {
+					flyCmd := exec.Command(flyPath, "-t", targetName, "abort-build", "-j", "my-pipeline/my-job", "--all-running", "-n")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gexec.Exit(0))
+
+					Expect(sess.Out).To(gbytes.Say(`my-pipeline/my-job #44 \(id 25, started\)`))
+					Expect(sess.Out).To(gbytes.Say(`my-pipeline/my-job #40 \(id 21, started\)`))
+					Expect(sess.Out).To(gbytes.Say("aborted my-pipeline/my-job #44"))
+					Expect(sess.Out).To(gbytes.Say("aborted my-pipeline/my-job #40"))
+				}).To(Change(func() int {
+					return len(atcServer.ReceivedRequests())
+				}).By(5))
+			})
+		})
+
+		Context("when asked to confirm", func() {
+			var stdin io.WriteCloser
+			var sess *gexec.Session
+
+			JustBeforeEach(func() {
+				var err error
+
+				flyCmd := exec.Command(flyPath, "-t", targetName, "abort-build", "-j", "my-pipeline/my-job", "--all-running")
+				stdin, err = flyCmd.StdinPipe()
+				Expect(err).NotTo(HaveOccurred())
+
+				sess, err = gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gbytes.Say(`my-pipeline/my-job #44 \(id 25, pending\)`))
+				Eventually(sess).Should(gbytes.Say(`abort these builds\? \[yN\]: `))
+			})
+
+			It("aborts nothing if the user says no", func() {
+				fmt.Fprintf(stdin, "n\n")
+
+				Eventually(sess).Should(gbytes.Say("bailing out"))
+				Eventually(sess).Should(gexec.Exit(0))
+
+				for _, request := range atcServer.ReceivedRequests() {
+					Expect(request.Method).To(Equal("GET"))
+				}
+			})
+
+			It("aborts the running builds if the user says yes", func() {
+				fmt.Fprintf(stdin, "y\n")
+
+				Eventually(sess).Should(gbytes.Say("aborted my-pipeline/my-job #44"))
+				Eventually(sess).Should(gbytes.Say("aborted my-pipeline/my-job #43"))
+				Eventually(sess).Should(gexec.Exit(0))
+			})
+		})
+	})
+
+	Context("when aborting all running builds of a pipeline", func() {
+		BeforeEach(func() {
+			config := atc.Config{
+				Jobs: atc.JobConfigs{
+					{Name: "my-job"},
+					{Name: "other-job"},
+				},
+			}
+
+			atcServer.SetHandler(3, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v1/pipelines/my-pipeline/config"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, atc.ConfigResponse{Config: &config}, http.Header{atc.ConfigVersionHeader: {"42"}}),
+			))
+
+			atcServer.SetHandler(4, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v1/pipelines/my-pipeline/jobs/my-job/builds", "limit=100"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Build{
+					{ID: 25, Name: "44", Status: "started", PipelineName: "my-pipeline", JobName: "my-job"},
+					{ID: 24, Name: "43", Status: "succeeded", PipelineName: "my-pipeline", JobName: "my-job"},
+				}, http.Header{
+					"Link": []string{`<` + atcServer.URL() + `/api/v1/pipelines/my-pipeline/jobs/my-job/builds?until=24&limit=100>; rel="next"`},
+				}),
+			))
+
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/my-pipeline/jobs/my-job/builds", "until=24&limit=100"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Build{
+						{ID: 20, Name: "42", Status: "pending", PipelineName: "my-pipeline", JobName: "my-job"},
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/my-pipeline/jobs/other-job/builds", "limit=100"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.Build{
+						{ID: 30, Name: "7", Status: "started", PipelineName: "my-pipeline", JobName: "other-job"},
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/25/abort"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/20/abort"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/30/abort"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+		})
+
+		It("aborts the running builds of every job", func() {
+			Expect(func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "abort-build", "-p", "my-pipeline", "--all-running", "-n")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				Expect(sess.Out).To(gbytes.Say(`my-pipeline/my-job #44 \(id 25, started\)`))
+				Expect(sess.Out).To(gbytes.Say(`my-pipeline/my-job #42 \(id 20, pending\)`))
+				Expect(sess.Out).To(gbytes.Say(`my-pipeline/other-job #7 \(id 30, started\)`))
+				Expect(sess.Out).To(gbytes.Say("aborted my-pipeline/my-job #44"))
+				Expect(sess.Out).To(gbytes.Say("aborted my-pipeline/my-job #42"))
+				Expect(sess.Out).To(gbytes.Say("aborted my-pipeline/other-job #7"))
+			}).To(Change(func() int {
+				return len(atcServer.ReceivedRequests())
+			}).By(7))
+		})
+	})
 })
@@ -0,0 +1,79 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("encrypt-vars and decrypt-vars", func() {
+		var tmpdir string
+		var varsPath string
+		var encryptedPath string
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir("", "fly-encrypt-vars")
+			Expect(err).NotTo(HaveOccurred())
+
+			varsPath = filepath.Join(tmpdir, "vars.yml")
+			encryptedPath = filepath.Join(tmpdir, "vars.yml.enc")
+
+			err = ioutil.WriteFile(varsPath, []byte("password: hunter2\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpdir)
+		})
+
+		run := func(passphrase string, args ...string) *gexec.Session {
+			flyCmd := exec.Command(flyPath, args...)
+			flyCmd.Env = append(os.Environ(), "FLY_VARS_PASSPHRASE="+passphrase)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			return sess
+		}
+
+		It("encrypts a variables file so that it can be decrypted again", func() {
+			sess := run("some-passphrase", "encrypt-vars", "-f", varsPath, "-o", encryptedPath)
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			encrypted, err := ioutil.ReadFile(encryptedPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(encrypted)).NotTo(ContainSubstring("hunter2"))
+
+			sess = run("some-passphrase", "decrypt-vars", "-f", encryptedPath)
+			Expect(sess.ExitCode()).To(Equal(0))
+			Expect(sess.Out).To(gbytes.Say("password: hunter2"))
+		})
+
+		It("fails to decrypt with the wrong passphrase", func() {
+			sess := run("some-passphrase", "encrypt-vars", "-f", varsPath, "-o", encryptedPath)
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			sess = run("some-other-passphrase", "decrypt-vars", "-f", encryptedPath)
+			Expect(sess.ExitCode()).To(Equal(1))
+			Expect(sess.Err).To(gbytes.Say("could not decrypt variables"))
+		})
+
+		It("refuses to encrypt a file twice", func() {
+			sess := run("some-passphrase", "encrypt-vars", "-f", varsPath, "-o", varsPath)
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			sess = run("some-passphrase", "encrypt-vars", "-f", varsPath)
+			Expect(sess.ExitCode()).To(Equal(1))
+			Expect(sess.Err).To(gbytes.Say("already encrypted"))
+		})
+	})
+})
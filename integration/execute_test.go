@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"syscall"
 	"time"
@@ -233,6 +234,147 @@ run:
 		Expect(uploadingBits).To(BeClosed())
 	})
 
+	Context("when --dry-run is specified", func() {
+		It("prints the plan without creating pipes or a build", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--dry-run")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(sess.Out).To(gbytes.Say(`name: fixture`))
+			Expect(sess.Out).To(gbytes.Say(`name: one-off`))
+
+			for _, request := range atcServer.ReceivedRequests() {
+				Expect(request.Method).To(Equal("GET"))
+			}
+		})
+	})
+
+	Context("when an image directory is given", func() {
+		var imageDir string
+		var imageFiles chan []string
+
+		BeforeEach(func() {
+			imageDir = filepath.Join(tmpdir, "image")
+
+			err := os.MkdirAll(filepath.Join(imageDir, "rootfs", "etc"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(imageDir, "rootfs", "etc", "some-file"), []byte("some-content"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(imageDir, "metadata.json"), []byte(`{"env":[],"user":""}`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			// would leave out the whole filesystem, were it not an image
+			err = ioutil.WriteFile(filepath.Join(imageDir, ".flyignore"), []byte("rootfs\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			imageFiles = make(chan []string, 1)
+
+			planFactory := atc.NewPlanFactory(0)
+
+			gets := (*expectedPlan.Do)[0].Aggregate
+			*gets = append(*gets, planFactory.NewPlan(atc.GetPlan{
+				Name: "fly-image",
+				Type: "archive",
+				Source: atc.Source{
+					"uri": atcServer.URL() + "/api/v1/pipes/some-image-pipe-id",
+				},
+			}))
+
+			(*expectedPlan.Do)[1].Task.ImageArtifactName = "fly-image"
+		})
+
+		JustBeforeEach(func() {
+			pipeIDs := []string{"some-pipe-id", "some-image-pipe-id"}
+
+			atcServer.RouteToHandler("POST", "/api/v1/pipes",
+				func(w http.ResponseWriter, r *http.Request) {
+					id := pipeIDs[0]
+					pipeIDs = pipeIDs[1:]
+
+					ghttp.RespondWithJSONEncoded(http.StatusCreated, atc.Pipe{
+						ID:       id,
+						ReadURL:  atcServer.URL() + "/api/v1/pipes/" + id,
+						WriteURL: atcServer.URL() + "/api/v1/pipes/" + id,
+					})(w, r)
+				},
+			)
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-image-pipe-id",
+				ghttp.CombineHandlers(
+					func(w http.ResponseWriter, req *http.Request) {
+						gr, err := gzip.NewReader(req.Body)
+						Expect(err).NotTo(HaveOccurred())
+
+						tr := tar.NewReader(gr)
+
+						names := []string{}
+						for {
+							hdr, err := tr.Next()
+							if err != nil {
+								break
+							}
+
+							names = append(names, hdr.Name)
+						}
+
+						imageFiles <- names
+					},
+					ghttp.RespondWith(200, ""),
+				),
+			)
+		})
+
+		It("runs the task with it as its image, uploading all of it", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--image-dir", imageDir)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming).Should(BeClosed())
+
+			var names []string
+			Eventually(imageFiles).Should(Receive(&names))
+			Expect(names).To(ContainElement(MatchRegexp(`^(\./)?metadata\.json$`)))
+			Expect(names).To(ContainElement(MatchRegexp(`^(\./)?rootfs/etc/some-file$`)))
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+
+		Context("when it does not have the layout of an image", func() {
+			BeforeEach(func() {
+				err := os.Remove(filepath.Join(imageDir, "metadata.json"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("prints an error without creating a build", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--image-dir", imageDir)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(sess.Err).To(gbytes.Say("must contain a metadata.json"))
+
+				for _, request := range atcServer.ReceivedRequests() {
+					Expect(request.URL.Path).NotTo(Equal("/api/v1/builds"))
+				}
+			})
+		})
+	})
+
 	Context("when the build config is invalid", func() {
 		BeforeEach(func() {
 			// missing platform and run path
@@ -386,6 +528,44 @@ run:
 
 		})
 
+		Context("When a missing input has a sibling directory of the same name", func() {
+			var siblingDir string
+
+			BeforeEach(func() {
+				siblingDir = filepath.Join(tmpdir, "something")
+
+				err := os.Mkdir(siblingDir, 0755)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("maps the input to it and prints the mapping", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--dry-run")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Err).To(gbytes.Say(`something\s+` + regexp.QuoteMeta(siblingDir)))
+				Expect(sess.Out).To(gbytes.Say(`name: something`))
+			})
+
+			It("maps it alongside the inputs given with -i", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "-i", "fixture=.", "--dry-run")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Err).To(gbytes.Say(`something\s+` + regexp.QuoteMeta(siblingDir)))
+			})
+		})
+
 		Context("When no inputs are passed", func() {
 			It("Prints an error", func() {
 				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
@@ -402,6 +582,158 @@ run:
 		})
 	})
 
+	Context("when an image is specified", func() {
+		BeforeEach(func() {
+			(*expectedPlan.Do)[1].Task.Config.Image = ""
+			(*expectedPlan.Do)[1].Task.Config.ImageResource = &atc.ImageResource{
+				Type: "docker-image",
+				Source: atc.Source{
+					"repository": "busybox",
+					"tag":        "latest",
+				},
+			}
+		})
+
+		It("runs the task with it instead of the configured image", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--image", "busybox:latest")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+	})
+
+	Context("when several task configs are specified", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				filepath.Join(tmpdir, "test.yml"),
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: fixture
+- name: built
+
+run:
+  path: ls
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(
+				taskConfigPath,
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: fixture
+
+outputs:
+- name: built
+
+run:
+  path: find
+  args: [.]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			planFactory := atc.NewPlanFactory(0)
+
+			expectedPlan = planFactory.NewPlan(atc.DoPlan{
+				planFactory.NewPlan(atc.AggregatePlan{
+					planFactory.NewPlan(atc.GetPlan{
+						Name: filepath.Base(buildDir),
+						Type: "archive",
+						Source: atc.Source{
+							"uri": atcServer.URL() + "/api/v1/pipes/some-pipe-id",
+						},
+					}),
+				}),
+				planFactory.NewPlan(atc.TaskPlan{
+					Name: "task",
+					Config: &atc.TaskConfig{
+						Platform: "some-platform",
+						Image:    "ubuntu",
+						Inputs: []atc.TaskInputConfig{
+							{Name: "fixture"},
+						},
+						Outputs: []atc.TaskOutputConfig{
+							{Name: "built"},
+						},
+						Run: atc.TaskRunConfig{
+							Path: "find",
+							Args: []string{"."},
+						},
+					},
+				}),
+				planFactory.NewPlan(atc.TaskPlan{
+					Name: "test",
+					Config: &atc.TaskConfig{
+						Platform: "some-platform",
+						Image:    "ubuntu",
+						Inputs: []atc.TaskInputConfig{
+							{Name: "fixture"},
+							{Name: "built"},
+						},
+						Run: atc.TaskRunConfig{
+							Path: "ls",
+						},
+					},
+				}),
+			})
+		})
+
+		It("runs the tasks in order, passing along their outputs", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "-c", filepath.Join(tmpdir, "test.yml"))
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).To(BeClosed())
+		})
+
+		It("does not allow arguments to be passed through", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "-c", filepath.Join(tmpdir, "test.yml"), "--", "-name", "foo")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("arguments can only be passed through to a single task"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
 	Context("when running with --privileged", func() {
 		BeforeEach(func() {
 			(*expectedPlan.Do)[1].Task.Privileged = true
@@ -428,6 +760,118 @@ run:
 		})
 	})
 
+	Context("when --task is specified without a job", func() {
+		It("prints an error", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "--task", "some-task")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("--task requires a job to be specified with --inputs-from"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
+
+	Context("when --task loads its config from a file in one of the job's inputs", func() {
+		BeforeEach(func() {
+			planFactory := atc.NewPlanFactory(0)
+
+			expectedPlan = planFactory.NewPlan(atc.DoPlan{
+				planFactory.NewPlan(atc.AggregatePlan{
+					planFactory.NewPlan(atc.GetPlan{
+						Name:    "source",
+						Type:    "git",
+						Source:  atc.Source{"uri": "https://example.com"},
+						Version: atc.Version{"ref": "abcdef"},
+					}),
+				}),
+				planFactory.NewPlan(atc.TaskPlan{
+					Name:       "one-off",
+					ConfigPath: "source/ci/unit.yml",
+					Params: atc.Params{
+						"FOO": "foo",
+						"BAR": "overridden",
+					},
+				}),
+			})
+		})
+
+		JustBeforeEach(func() {
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/config",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/some-pipeline/config"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, atc.ConfigResponse{
+						Config: &atc.Config{
+							Resources: atc.ResourceConfigs{
+								{
+									Name:   "some-resource",
+									Type:   "git",
+									Source: atc.Source{"uri": "https://example.com"},
+								},
+							},
+							Jobs: atc.JobConfigs{
+								{
+									Name: "some-job",
+									Plan: atc.PlanSequence{
+										{Get: "source", Resource: "some-resource"},
+										{
+											Task:           "unit",
+											TaskConfigPath: "source/ci/unit.yml",
+											Params: atc.Params{
+												"FOO": "foo",
+												"BAR": "bar",
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+				),
+			)
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/inputs",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/inputs"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.BuildInput{
+						{
+							Name:     "source",
+							Type:     "git",
+							Resource: "some-resource",
+							Source:   atc.Source{"uri": "https://example.com"},
+							Version:  atc.Version{"ref": "abcdef"},
+						},
+					}),
+				),
+			)
+		})
+
+		It("runs the task with the config loaded by the build from the job's input", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e",
+				"--inputs-from", "some-pipeline/some-job",
+				"--task", "unit",
+				"--param", "BAR=overridden",
+			)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+			Eventually(sess.Out).Should(gbytes.Say("executing build 128"))
+
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(uploadingBits).NotTo(BeClosed())
+		})
+	})
+
 	Context("when running with bogus flags", func() {
 		It("exits 1", func() {
 			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--bogus-flag")
@@ -474,6 +918,287 @@ run:
 		})
 	})
 
+	Context("when params are given with --param and --params-from", func() {
+		var paramsFile string
+
+		BeforeEach(func() {
+			paramsFile = filepath.Join(tmpdir, "params.env")
+
+			err := ioutil.WriteFile(paramsFile, []byte("FOO=from-file\nBAZ=from-file\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			(*expectedPlan.Do)[1].Task.Config.Params = map[string]string{
+				"FOO":   "from-file",
+				"BAZ":   "from-flag",
+				"X":     "1",
+				"BOGUS": "bogus",
+			}
+		})
+
+		It("overrides the build's parameter values and warns about undeclared ones", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(
+				flyPath, "-t", targetName, "e", "-c", taskConfigPath,
+				"--params-from", paramsFile,
+				"--param", "BAZ=from-flag",
+				"--param", "BOGUS=bogus",
+			)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("param `BOGUS` is not declared by the task"))
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+
+		It("masks them in the printed plan", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--param", "BAZ=some-secret", "--dry-run")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(sess.Out).To(gbytes.Say(`BAZ: \(\(redacted\)\)`))
+			Expect(sess.Out.Contents()).NotTo(ContainSubstring("some-secret"))
+		})
+	})
+
+	Context("when the build events can not be streamed", func() {
+		var aborted chan struct{}
+
+		JustBeforeEach(func() {
+			aborted = make(chan struct{})
+
+			atcServer.RouteToHandler("GET", "/api/v1/builds/128/events",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds/128/events"),
+					ghttp.RespondWith(http.StatusInternalServerError, ""),
+				),
+			)
+			atcServer.RouteToHandler("POST", "/api/v1/builds/128/abort",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/128/abort"),
+					func(w http.ResponseWriter, r *http.Request) {
+						close(aborted)
+					},
+				),
+			)
+		})
+
+		It("aborts the build without uploading its inputs", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(aborted, 5.0).Should(BeClosed())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+
+			Expect(uploadingBits).NotTo(BeClosed())
+		})
+	})
+
+	Context("when the build times out", func() {
+		var aborted chan struct{}
+
+		JustBeforeEach(func() {
+			aborted = make(chan struct{})
+
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/128/abort"),
+					func(w http.ResponseWriter, r *http.Request) {
+						close(aborted)
+					},
+				),
+			)
+		})
+
+		It("aborts the build and exits with a distinct exit code", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--timeout", "1s")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			Eventually(aborted, 5.0).Should(BeClosed())
+			Eventually(sess.Err).Should(gbytes.Say("build timed out after 1s"))
+
+			events <- event.Status{Status: atc.StatusAborted}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(6))
+		})
+	})
+
+	Context("when attempts are specified", func() {
+		BeforeEach(func() {
+			planFactory := atc.NewPlanFactory(0)
+
+			taskPlan := (*expectedPlan.Do)[1]
+			(*expectedPlan.Do)[1] = planFactory.NewPlan(atc.RetryPlan{
+				taskPlan,
+				planFactory.NewPlan(*taskPlan.Task),
+				planFactory.NewPlan(*taskPlan.Task),
+			})
+		})
+
+		It("retries the task", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--attempts", "3")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			// sync with after create
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			Eventually(uploadingBits).Should(BeClosed())
+
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+
+		Context("when fewer than 1 attempt is given", func() {
+			It("prints an error without creating a build", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--attempts", "0")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("--attempts must be at least 1"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(streaming).NotTo(BeClosed())
+			})
+		})
+	})
+
+	Context("when watching the inputs for changes", func() {
+		var aborted chan struct{}
+		var restarted chan struct{}
+		var uploads chan struct{}
+
+		JustBeforeEach(func() {
+			aborted = make(chan struct{})
+			restarted = make(chan struct{})
+			uploads = make(chan struct{}, 2)
+
+			builds := 0
+
+			atcServer.RouteToHandler("POST", "/api/v1/builds",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds"),
+					VerifyPlan(expectedPlan),
+					func(w http.ResponseWriter, r *http.Request) {
+						builds++
+
+						if builds == 1 {
+							w.WriteHeader(201)
+							w.Write([]byte(`{"id":128}`))
+						} else {
+							close(restarted)
+
+							w.WriteHeader(201)
+							w.Write([]byte(`{"id":129}`))
+						}
+					},
+				),
+			)
+			atcServer.RouteToHandler("POST", "/api/v1/builds/128/abort",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/128/abort"),
+					func(w http.ResponseWriter, r *http.Request) {
+						close(aborted)
+					},
+				),
+			)
+			atcServer.RouteToHandler("GET", "/api/v1/builds/129/events",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds/129/events"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+						w.WriteHeader(http.StatusOK)
+
+						payload, err := json.Marshal(event.Message{Event: event.Status{Status: atc.StatusSucceeded}})
+						Expect(err).NotTo(HaveOccurred())
+
+						err = sse.Event{ID: "0", Name: "event", Data: payload}.Write(w)
+						Expect(err).NotTo(HaveOccurred())
+
+						err = sse.Event{Name: "end"}.Write(w)
+						Expect(err).NotTo(HaveOccurred())
+					},
+				),
+			)
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-pipe-id",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/api/v1/pipes/some-pipe-id"),
+					func(w http.ResponseWriter, req *http.Request) {
+						_, err := ioutil.ReadAll(req.Body)
+						Expect(err).NotTo(HaveOccurred())
+
+						uploads <- struct{}{}
+					},
+					ghttp.RespondWith(200, ""),
+				),
+			)
+		})
+
+		It("aborts the running build and starts a new one when an input changes", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--watch-files")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+			Eventually(uploads, 5).Should(Receive())
+
+			err = ioutil.WriteFile(filepath.Join(buildDir, "new-file"), []byte("changed"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(aborted, 5).Should(BeClosed())
+			Eventually(sess.Err).Should(gbytes.Say("inputs changed, starting over"))
+
+			events <- event.Status{Status: atc.StatusAborted}
+			close(events)
+
+			Eventually(restarted, 5).Should(BeClosed())
+			Eventually(sess.Out, 5).Should(gbytes.Say("executing build 129"))
+			Eventually(uploads, 5).Should(Receive())
+
+			Eventually(sess.Err, 5).Should(gbytes.Say("waiting for changes"))
+
+			sess.Kill()
+			Eventually(sess).Should(gexec.Exit())
+		})
+	})
+
 	Context("when the build is interrupted", func() {
 		var aborted chan struct{}
 
@@ -602,6 +1327,48 @@ run:
 		})
 	})
 
+	Context("when an input fails to upload", func() {
+		var aborted chan struct{}
+
+		JustBeforeEach(func() {
+			aborted = make(chan struct{})
+
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-pipe-id",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/api/v1/pipes/some-pipe-id"),
+					ghttp.RespondWith(500, "oh no"),
+				),
+			)
+			atcServer.RouteToHandler("POST", "/api/v1/builds/128/abort",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds/128/abort"),
+					func(w http.ResponseWriter, r *http.Request) {
+						close(aborted)
+					},
+				),
+			)
+		})
+
+		It("aborts the build and exits 4", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			Eventually(aborted, 5.0).Should(BeClosed())
+			Eventually(sess.Err).Should(gbytes.Say("failed to upload input `fixture`"))
+
+			events <- event.Status{Status: atc.StatusAborted}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(4))
+		})
+	})
+
 	Context("when the build succeeds", func() {
 		It("exits 0", func() {
 			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
@@ -2,6 +2,7 @@ package integration_test
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"encoding/json"
 	"fmt"
@@ -304,6 +305,126 @@ run:
 			})
 		})
 
+		Context("when the output path is a .tgz archive", func() {
+			It("saves the archive as is", func() {
+				archivePath := filepath.Join(outputDir, "some-dir.tgz")
+
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--output", "some-dir="+archivePath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				archive, err := os.Open(archivePath)
+				Expect(err).NotTo(HaveOccurred())
+				defer archive.Close()
+
+				gr, err := gzip.NewReader(archive)
+				Expect(err).NotTo(HaveOccurred())
+
+				hdr, err := tar.NewReader(gr).Next()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(hdr.Name).To(Equal("some-file"))
+			})
+		})
+
+		Context("when the output path is -", func() {
+			It("writes a tar to stdout and the build output to stderr", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--output", "some-dir=-")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, nil, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				events <- event.Log{Payload: "sup"}
+				close(events)
+
+				Eventually(sess.Err).Should(gbytes.Say("sup"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				tr := tar.NewReader(bytes.NewReader(sess.Out.Contents()))
+
+				hdr, err := tr.Next()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(hdr.Name).To(Equal("some-file"))
+
+				data, err := ioutil.ReadAll(tr)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(data).To(Equal([]byte("tar-contents")))
+			})
+		})
+
+		Context("when --clean-outputs is specified", func() {
+			BeforeEach(func() {
+				err := ioutil.WriteFile(filepath.Join(outputDir, "stale-file"), []byte("stale"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("empties the directory before downloading into it", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--output", "some-dir="+outputDir, "--clean-outputs")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				outputFiles, err := ioutil.ReadDir(outputDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(outputFiles).To(HaveLen(1))
+				Expect(outputFiles[0].Name()).To(Equal("some-file"))
+			})
+		})
+
+		Context("when the output cannot be downloaded", func() {
+			JustBeforeEach(func() {
+				atcServer.RouteToHandler("GET", "/api/v1/pipes/output-pipe-id",
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/pipes/output-pipe-id"),
+						ghttp.RespondWith(http.StatusInternalServerError, ""),
+					),
+				)
+			})
+
+			It("exits with a distinct exit code", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--output", "some-dir="+outputDir)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				Eventually(sess.Err).Should(gbytes.Say("failed to download output `some-dir`: bad response downloading bits"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(5))
+			})
+		})
+
 		Context("when the task does not specify those outputs", func() {
 			It("exits 1", func() {
 				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "-o", "wrong-output=wrong-path")
@@ -318,5 +439,43 @@ run:
 				Expect(sess.ExitCode()).To(Equal(1))
 			})
 		})
+
+		Context("when watching the inputs for changes and the output is inside an input", func() {
+			It("does not start the build over once the output is downloaded", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--output", "some-dir=./some-dir", "--watch-files")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				// sync with after create
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				Eventually(sess.Err, 5).Should(gbytes.Say("waiting for changes"))
+
+				data, err := ioutil.ReadFile(filepath.Join(buildDir, "some-dir", "some-file"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(data).To(Equal([]byte("tar-contents")))
+
+				builds := func() int {
+					created := 0
+					for _, req := range atcServer.ReceivedRequests() {
+						if req.Method == "POST" && req.URL.Path == "/api/v1/builds" {
+							created++
+						}
+					}
+
+					return created
+				}
+
+				Consistently(builds, 3).Should(Equal(1))
+				Expect(sess.Err).NotTo(gbytes.Say("inputs changed"))
+
+				sess.Kill()
+				Eventually(sess).Should(gexec.Exit())
+			})
+		})
 	})
 })
@@ -262,4 +262,139 @@ run:
 		<-sess.Exited
 		Expect(sess).To(gexec.Exit(0))
 	})
+
+	Context("when basing inputs on a specific build of the job", func() {
+		BeforeEach(func() {
+			planFactory := atc.NewPlanFactory(0)
+
+			expectedPlan = planFactory.NewPlan(atc.DoPlan{
+				planFactory.NewPlan(atc.AggregatePlan{
+					planFactory.NewPlan(atc.GetPlan{
+						Name: "some-input",
+						Type: "archive",
+						Source: atc.Source{
+							"uri": atcServer.URL() + "/api/v1/pipes/some-pipe-id",
+						},
+					}),
+					planFactory.NewPlan(atc.GetPlan{
+						Name:    "some-other-input",
+						Type:    "git",
+						Source:  atc.Source{"uri": "https://example.com"},
+						Params:  atc.Params{"some": "other-params"},
+						Version: atc.Version{"some": "old-version"},
+						Tags:    atc.Tags{"tag-1", "tag-2"},
+					}),
+				}),
+				planFactory.NewPlan(atc.TaskPlan{
+					Name: "one-off",
+					Config: &atc.TaskConfig{
+						Platform: "some-platform",
+						Image:    "ubuntu",
+						Inputs: []atc.TaskInputConfig{
+							{Name: "some-input"},
+							{Name: "some-other-input"},
+						},
+						Params: map[string]string{
+							"FOO": "bar",
+							"BAZ": "buzz",
+							"X":   "1",
+						},
+						Run: atc.TaskRunConfig{
+							Path: "find",
+							Args: []string{"."},
+						},
+					},
+				}),
+			})
+		})
+
+		JustBeforeEach(func() {
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/builds/42",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/builds/42"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, atc.Build{
+						ID:           23,
+						Name:         "42",
+						Status:       "failed",
+						JobName:      "some-job",
+						PipelineName: "some-pipeline",
+					}),
+				),
+			)
+			atcServer.RouteToHandler("GET", "/api/v1/builds/23/resources",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds/23/resources"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, atc.BuildInputsOutputs{
+						Inputs: []atc.PublicBuildInput{
+							{Name: "some-input", Resource: "some-resource", Version: atc.Version{"some": "old-version"}},
+							{Name: "some-other-input", Resource: "some-other-resource", Version: atc.Version{"some": "old-version"}},
+						},
+					}),
+				),
+			)
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/config",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/some-pipeline/config"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, atc.ConfigResponse{
+						Config: &atc.Config{
+							Resources: atc.ResourceConfigs{
+								{
+									Name:   "some-resource",
+									Type:   "git",
+									Source: atc.Source{"uri": "https://internet.com"},
+								},
+								{
+									Name:   "some-other-resource",
+									Type:   "git",
+									Source: atc.Source{"uri": "https://example.com"},
+								},
+							},
+							Jobs: atc.JobConfigs{
+								{
+									Name: "some-job",
+									Plan: atc.PlanSequence{
+										{
+											Aggregate: &atc.PlanSequence{
+												{Get: "some-input", Resource: "some-resource", Params: atc.Params{"some": "params"}, Tags: atc.Tags{"tag-1", "tag-2"}},
+												{Get: "some-other-input", Resource: "some-other-resource", Params: atc.Params{"some": "other-params"}, Tags: atc.Tags{"tag-1", "tag-2"}},
+											},
+										},
+									},
+								},
+							},
+						},
+					}, http.Header{atc.ConfigVersionHeader: {"42"}}),
+				),
+			)
+
+			// the job's current inputs are not available when it can not be
+			// scheduled, so they must not be needed
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/inputs",
+				ghttp.RespondWith(http.StatusNotFound, ""),
+			)
+		})
+
+		It("uses the versions that the build ran with", func() {
+			flyCmd := exec.Command(
+				flyPath, "-t", targetName, "e",
+				"--inputs-from-build", "some-pipeline/some-job/42",
+				"--input", fmt.Sprintf("some-input=%s", buildDir),
+				"--config", filepath.Join(buildDir, "task.yml"),
+			)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming).Should(BeClosed())
+			Eventually(uploading).Should(BeClosed())
+
+			events <- event.Log{Payload: "sup"}
+			close(events)
+
+			Eventually(sess.Out).Should(gbytes.Say("sup"))
+
+			<-sess.Exited
+			Expect(sess).To(gexec.Exit(0))
+		})
+	})
 })
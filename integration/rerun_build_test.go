@@ -0,0 +1,319 @@
+package integration_test
+
+import (
+	"net/http"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/atc"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("rerun-build", func() {
+		var (
+			config       atc.Config
+			buildOutputs []atc.PublicBuildOutput
+			expectedPlan atc.Plan
+		)
+
+		BeforeEach(func() {
+			config = atc.Config{
+				Resources: atc.ResourceConfigs{
+					{
+						Name:   "some-resource",
+						Type:   "git",
+						Source: atc.Source{"uri": "https://example.com/repo.git"},
+					},
+				},
+				Jobs: atc.JobConfigs{
+					{
+						Name: "some-job",
+						Plan: atc.PlanSequence{
+							{Get: "some-input", Resource: "some-resource"},
+							{Task: "some-task", TaskConfigPath: "some-input/task.yml"},
+							{Put: "some-resource"},
+						},
+					},
+				},
+			}
+
+			buildOutputs = nil
+
+			planFactory := atc.NewPlanFactory(0)
+
+			expectedPlan = planFactory.NewPlan(atc.DoPlan{
+				planFactory.NewPlan(atc.AggregatePlan{
+					planFactory.NewPlan(atc.GetPlan{
+						Name:    "some-input",
+						Type:    "git",
+						Source:  atc.Source{"uri": "https://example.com/repo.git"},
+						Version: atc.Version{"ref": "old-ref"},
+					}),
+				}),
+				planFactory.NewPlan(atc.TaskPlan{
+					Name:       "some-task",
+					ConfigPath: "some-input/task.yml",
+				}),
+			})
+		})
+
+		JustBeforeEach(func() {
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/builds/42"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, atc.Build{ID: 23, Name: "42"}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/pipelines/some-pipeline/config"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, atc.ConfigResponse{Config: &config}, http.Header{atc.ConfigVersionHeader: {"42"}}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/builds/23/resources"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, atc.BuildInputsOutputs{
+						Inputs: []atc.PublicBuildInput{
+							{
+								Name:     "some-input",
+								Resource: "some-resource",
+								Type:     "git",
+								Version:  atc.Version{"ref": "old-ref"},
+							},
+						},
+						Outputs: buildOutputs,
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds"),
+					VerifyPlan(expectedPlan),
+					ghttp.RespondWith(http.StatusCreated, `{"id":128}`),
+				),
+			)
+		})
+
+		It("creates a build pinned to the inputs of the original build", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "rerun-build", "-j", "some-pipeline/some-job", "-b", "42")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess).Should(gexec.Exit(0))
+
+			Expect(sess.Out).To(gbytes.Say("started rerun of some-pipeline/some-job #42 as build 128"))
+		})
+
+		Context("when the steps have hooks, mappings, retries and timeouts", func() {
+			BeforeEach(func() {
+				resourceTypes := atc.ResourceTypes{
+					{
+						Name:   "some-type",
+						Type:   "docker-image",
+						Source: atc.Source{"repository": "some-type-resource"},
+					},
+				}
+
+				config.ResourceTypes = resourceTypes
+				config.Resources[0].Type = "some-type"
+				config.Jobs[0].Plan = atc.PlanSequence{
+					{
+						Get:      "some-input",
+						Resource: "some-resource",
+						Params:   atc.Params{"submodules": "none"},
+						Tags:     atc.Tags{"some-tag"},
+						Success:  &atc.PlanConfig{Task: "after-get", TaskConfigPath: "some-input/after-get.yml"},
+					},
+					{
+						Task:              "some-task",
+						TaskConfigPath:    "some-input/task.yml",
+						InputMapping:      map[string]string{"code": "some-input"},
+						OutputMapping:     map[string]string{"out": "some-output"},
+						ImageArtifactName: "some-input",
+						Attempts:          2,
+						Timeout:           "1h",
+						Failure:           &atc.PlanConfig{Task: "notify", TaskConfigPath: "some-input/notify.yml"},
+						Ensure:            &atc.PlanConfig{Put: "some-resource"},
+					},
+				}
+
+				planFactory := atc.NewPlanFactory(0)
+
+				attempt := func() atc.Plan {
+					return planFactory.NewPlan(atc.OnFailurePlan{
+						Step: planFactory.NewPlan(atc.TimeoutPlan{
+							Duration: "1h",
+							Step: planFactory.NewPlan(atc.TaskPlan{
+								Name:              "some-task",
+								ConfigPath:        "some-input/task.yml",
+								InputMapping:      map[string]string{"code": "some-input"},
+								OutputMapping:     map[string]string{"out": "some-output"},
+								ImageArtifactName: "some-input",
+								ResourceTypes:     resourceTypes,
+							}),
+						}),
+						Next: planFactory.NewPlan(atc.TaskPlan{
+							Name:          "notify",
+							ConfigPath:    "some-input/notify.yml",
+							ResourceTypes: resourceTypes,
+						}),
+					})
+				}
+
+				expectedPlan = planFactory.NewPlan(atc.DoPlan{
+					planFactory.NewPlan(atc.AggregatePlan{
+						planFactory.NewPlan(atc.GetPlan{
+							Name:          "some-input",
+							Type:          "some-type",
+							Source:        atc.Source{"uri": "https://example.com/repo.git"},
+							Params:        atc.Params{"submodules": "none"},
+							Tags:          atc.Tags{"some-tag"},
+							Version:       atc.Version{"ref": "old-ref"},
+							ResourceTypes: resourceTypes,
+						}),
+					}),
+					planFactory.NewPlan(atc.TaskPlan{
+						Name:          "after-get",
+						ConfigPath:    "some-input/after-get.yml",
+						ResourceTypes: resourceTypes,
+					}),
+					planFactory.NewPlan(atc.RetryPlan{attempt(), attempt()}),
+				})
+			})
+
+			It("carries them over, skipping the put", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "rerun-build", "-j", "some-pipeline/some-job", "-b", "42")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				Expect(sess.Out).To(gbytes.Say("started rerun of some-pipeline/some-job #42 as build 128"))
+			})
+		})
+
+		Context("when a task consumes the artifact of a put", func() {
+			BeforeEach(func() {
+				config.Jobs[0].Plan = atc.PlanSequence{
+					{Get: "some-input", Resource: "some-resource"},
+					{Put: "some-resource"},
+					{
+						Task:           "some-task",
+						TaskConfigPath: "some-input/task.yml",
+						InputMapping:   map[string]string{"code": "some-resource"},
+					},
+				}
+			})
+
+			Context("when the put produced a version", func() {
+				BeforeEach(func() {
+					buildOutputs = []atc.PublicBuildOutput{
+						{
+							Resource: "some-resource",
+							Version:  atc.Version{"ref": "new-ref"},
+						},
+					}
+
+					planFactory := atc.NewPlanFactory(0)
+
+					expectedPlan = planFactory.NewPlan(atc.DoPlan{
+						planFactory.NewPlan(atc.AggregatePlan{
+							planFactory.NewPlan(atc.GetPlan{
+								Name:    "some-input",
+								Type:    "git",
+								Source:  atc.Source{"uri": "https://example.com/repo.git"},
+								Version: atc.Version{"ref": "old-ref"},
+							}),
+							planFactory.NewPlan(atc.GetPlan{
+								Name:    "some-resource",
+								Type:    "git",
+								Source:  atc.Source{"uri": "https://example.com/repo.git"},
+								Version: atc.Version{"ref": "new-ref"},
+							}),
+						}),
+						planFactory.NewPlan(atc.TaskPlan{
+							Name:         "some-task",
+							ConfigPath:   "some-input/task.yml",
+							InputMapping: map[string]string{"code": "some-resource"},
+						}),
+					})
+				})
+
+				It("pins the artifact to the version that was put", func() {
+					flyCmd := exec.Command(flyPath, "-t", targetName, "rerun-build", "-j", "some-pipeline/some-job", "-b", "42")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gexec.Exit(0))
+
+					Expect(sess.Out).To(gbytes.Say("started rerun of some-pipeline/some-job #42 as build 128"))
+				})
+			})
+
+			Context("when the put produced no version", func() {
+				It("prints an error", func() {
+					flyCmd := exec.Command(flyPath, "-t", targetName, "rerun-build", "-j", "some-pipeline/some-job", "-b", "42")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gexec.Exit(1))
+
+					Expect(sess.Err).To(gbytes.Say("error: put `some-resource` did not produce a version in build 42, so the steps consuming it can not be rerun"))
+				})
+			})
+		})
+
+		Context("when the job can not currently be scheduled", func() {
+			BeforeEach(func() {
+				config.Jobs[0].Plan[0].Passed = []string{"some-job-with-no-builds"}
+			})
+
+			It("still takes the inputs from the pipeline config", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "rerun-build", "-j", "some-pipeline/some-job", "-b", "42")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+			})
+		})
+
+		Context("when the resource of an input is no longer configured", func() {
+			BeforeEach(func() {
+				config.Resources = atc.ResourceConfigs{}
+			})
+
+			It("prints an error", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "rerun-build", "-j", "some-pipeline/some-job", "-b", "42")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+
+				Expect(sess.Err).To(gbytes.Say("error: resource `some-resource` of input `some-input` is no longer configured for the pipeline"))
+			})
+		})
+
+		Context("when an input of the build is no longer configured for the job", func() {
+			BeforeEach(func() {
+				config.Jobs[0].Plan = config.Jobs[0].Plan[1:]
+			})
+
+			It("prints an error", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "rerun-build", "-j", "some-pipeline/some-job", "-b", "42")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+
+				Expect(sess.Err).To(gbytes.Say("error: input `some-input` of build 42 is no longer configured for the job"))
+			})
+		})
+	})
+})
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -22,6 +23,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/template"
 )
 
 var _ = Describe("Fly CLI", func() {
@@ -148,6 +150,88 @@ var _ = Describe("Fly CLI", func() {
 				)
 			})
 
+			Context("when a template variable is not provided", func() {
+				It("fails before showing the diff", func() {
+					flyCmd := exec.Command(
+						flyPath, "-t", targetName,
+						"set-pipeline",
+						"--pipeline", "awesome-pipeline",
+						"-c", "fixtures/testConfig.yml",
+						"--var", "resource-type=template-type",
+					)
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(1))
+
+					Expect(sess.Err).To(gbytes.Say("missing template variables: resource-key"))
+				})
+			})
+
+			Context("when the diff contains secrets", func() {
+				BeforeEach(func() {
+					path, err := atc.Routes.CreatePathForRoute(atc.SaveConfig, rata.Params{"pipeline_name": "awesome-pipeline"})
+					Expect(err).NotTo(HaveOccurred())
+
+					atcServer.RouteToHandler("PUT", path, ghttp.RespondWith(http.StatusOK, `{}`))
+				})
+
+				It("masks them", func() {
+					flyCmd := exec.Command(
+						flyPath, "-t", targetName,
+						"set-pipeline",
+						"--pipeline", "awesome-pipeline",
+						"-c", "fixtures/testConfig.yml",
+						"--var", "resource-type=new-secret-type",
+						"--var", "resource-key=newsecret",
+						"--non-interactive",
+					)
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say("configuration updated"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					Expect(sess.Out).To(gbytes.Say("resource some-other-resource has changed"))
+					Expect(sess.Out.Contents()).To(ContainSubstring(ansi.Color("secret_key: ((redacted))", "green")))
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("newsecret"))
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("verysecret"))
+
+					// any variable may be a secret
+					Expect(sess.Out.Contents()).To(ContainSubstring(ansi.Color("type: ((redacted))", "green")))
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("new-secret-type"))
+				})
+
+				It("shows them with --show-secrets", func() {
+					flyCmd := exec.Command(
+						flyPath, "-t", targetName,
+						"set-pipeline",
+						"--pipeline", "awesome-pipeline",
+						"-c", "fixtures/testConfig.yml",
+						"--var", "resource-type=new-secret-type",
+						"--var", "resource-key=newsecret",
+						"--non-interactive",
+						"--show-secrets",
+					)
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say("configuration updated"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+
+					Expect(sess.Out.Contents()).To(ContainSubstring(ansi.Color("type: new-secret-type", "green")))
+					Expect(sess.Out.Contents()).To(ContainSubstring(ansi.Color("secret_key: newsecret", "green")))
+				})
+			})
+
 			Context("when configuring with templated keys succeeds", func() {
 				BeforeEach(func() {
 					path, err := atc.Routes.CreatePathForRoute(atc.SaveConfig, rata.Params{"pipeline_name": "awesome-pipeline"})
@@ -200,6 +284,118 @@ var _ = Describe("Fly CLI", func() {
 					}).By(3))
 				})
 
+				It("templates structured values given with --yaml-var", func() {
+					flyCmd := exec.Command(
+						flyPath, "-t", targetName,
+						"set-pipeline",
+						"--pipeline", "awesome-pipeline",
+						"-c", "fixtures/testStructuredConfig.yml",
+						"--yaml-var", "other-source={secret_key: verysecret}",
+						"--load-vars-from", "fixtures/vars.yml",
+						"--non-interactive",
+					)
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say("configuration updated"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+				})
+
+				Context("when a variables file is encrypted", func() {
+					var tmpdir string
+					var encryptedPath string
+					var keyPath string
+
+					BeforeEach(func() {
+						var err error
+						tmpdir, err = ioutil.TempDir("", "fly-encrypted-vars")
+						Expect(err).NotTo(HaveOccurred())
+
+						plaintext, err := ioutil.ReadFile("fixtures/vars.yml")
+						Expect(err).NotTo(HaveOccurred())
+
+						encrypted, err := template.EncryptVariables(plaintext, []byte("some-passphrase"))
+						Expect(err).NotTo(HaveOccurred())
+
+						encryptedPath = filepath.Join(tmpdir, "vars.yml")
+						err = ioutil.WriteFile(encryptedPath, encrypted, 0600)
+						Expect(err).NotTo(HaveOccurred())
+
+						keyPath = filepath.Join(tmpdir, "key")
+						err = ioutil.WriteFile(keyPath, []byte("some-passphrase\n"), 0600)
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					AfterEach(func() {
+						os.RemoveAll(tmpdir)
+					})
+
+					It("decrypts it with the passphrase in --vars-key-file", func() {
+						flyCmd := exec.Command(
+							flyPath, "-t", targetName,
+							"set-pipeline",
+							"--pipeline", "awesome-pipeline",
+							"-c", "fixtures/testConfig.yml",
+							"--var", "resource-key=verysecret",
+							"--load-vars-from", encryptedPath,
+							"--vars-key-file", keyPath,
+							"--non-interactive",
+						)
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess).Should(gbytes.Say("configuration updated"))
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(0))
+					})
+
+					It("fails without a passphrase", func() {
+						flyCmd := exec.Command(
+							flyPath, "-t", targetName,
+							"set-pipeline",
+							"--pipeline", "awesome-pipeline",
+							"-c", "fixtures/testConfig.yml",
+							"--var", "resource-key=verysecret",
+							"--load-vars-from", encryptedPath,
+							"--non-interactive",
+						)
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(1))
+
+						Expect(sess.Err).To(gbytes.Say("no passphrase given for encrypted variables; use --vars-key-file"))
+					})
+				})
+
+				It("templates values from the environment and from commands", func() {
+					flyCmd := exec.Command(
+						flyPath, "-t", targetName,
+						"set-pipeline",
+						"--pipeline", "awesome-pipeline",
+						"-c", "fixtures/testConfig.yml",
+						"--vars-from-env", "FLY_VAR_",
+						"--vars-from-exec", "echo 'resource-key: verysecret'",
+						"--non-interactive",
+					)
+					flyCmd.Env = append(os.Environ(), "FLY_VAR_RESOURCE_TYPE=template-type")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say("configuration updated"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(0))
+				})
+
 				Context("when the --non-interactive is passed", func() {
 					It("parses the config file and sends it to the ATC without interaction", func() {
 						Expect(func() {
@@ -433,6 +629,91 @@ var _ = Describe("Fly CLI", func() {
 				})
 			})
 
+			Context("when only checking for changes", func() {
+				receivedPUT := func() bool {
+					for _, request := range atcServer.ReceivedRequests() {
+						if request.Method == "PUT" {
+							return true
+						}
+					}
+
+					return false
+				}
+
+				Context("when there are no changes", func() {
+					It("says so and exits 0 without applying", func() {
+						flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", configFile.Name(), "--check")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(0))
+
+						Expect(sess.Out).To(gbytes.Say("no changes to apply"))
+						Expect(receivedPUT()).To(BeFalse())
+					})
+				})
+
+				Context("when there are changes", func() {
+					BeforeEach(func() {
+						newJobs := make(atc.JobConfigs, len(config.Jobs))
+						copy(newJobs, config.Jobs)
+
+						changedConfig.Jobs = append(newJobs, atc.JobConfig{Name: "some-new-job"})
+					})
+
+					It("prints the diff and exits 1 without applying", func() {
+						flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", configFile.Name(), "--check")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(1))
+
+						Expect(sess.Out).To(gbytes.Say("job some-new-job has been added"))
+						Expect(sess.Out).NotTo(gbytes.Say("apply configuration"))
+						Expect(receivedPUT()).To(BeFalse())
+					})
+				})
+
+				Context("when template variables are missing", func() {
+					It("exits 2 rather than reporting changes", func() {
+						flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", "fixtures/testConfig.yml", "--check")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(2))
+
+						Expect(sess.Err).To(gbytes.Say("missing template variables: resource-type, resource-key"))
+						Expect(receivedPUT()).To(BeFalse())
+					})
+				})
+
+				Context("when the config can not be parsed", func() {
+					JustBeforeEach(func() {
+						err := ioutil.WriteFile(configFile.Name(), []byte("jobs: {"), 0644)
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("exits 2 rather than reporting changes", func() {
+						flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", configFile.Name(), "--check")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(2))
+
+						Expect(sess.Err).To(gbytes.Say("failed to parse configuration file"))
+						Expect(receivedPUT()).To(BeFalse())
+					})
+				})
+			})
+
 			Context("when configuring fails", func() {
 				BeforeEach(func() {
 					path, err := atc.Routes.CreatePathForRoute(atc.SaveConfig, rata.Params{"pipeline_name": "awesome-pipeline"})
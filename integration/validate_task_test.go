@@ -0,0 +1,141 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("validate-task", func() {
+		var tmpdir string
+		var taskConfigPath string
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir("", "fly-validate-task")
+			Expect(err).NotTo(HaveOccurred())
+
+			taskConfigPath = filepath.Join(tmpdir, "task.yml")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpdir)
+		})
+
+		writeConfig := func(contents string) {
+			err := ioutil.WriteFile(taskConfigPath, []byte(contents), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Context("when the task config is sound", func() {
+			BeforeEach(func() {
+				writeConfig(`---
+platform: linux
+
+inputs:
+- name: source
+
+run:
+  path: source/ci/test
+`)
+			})
+
+			It("exits 0", func() {
+				flyCmd := exec.Command(flyPath, "validate-task", "-c", taskConfigPath)
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Out).To(gbytes.Say("looks good"))
+			})
+		})
+
+		Context("when the task config only has warnings", func() {
+			BeforeEach(func() {
+				writeConfig(`---
+platform: linux
+
+inputs:
+- name: source
+- name: unused
+
+run:
+  path: source/ci/test
+`)
+			})
+
+			It("prints them and exits 0", func() {
+				flyCmd := exec.Command(flyPath, "validate-task", "-c", taskConfigPath)
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Out).To(gbytes.Say("warning: input `unused` is not referenced by run.path, run.args or run.dir \\(unreferenced-input\\)"))
+			})
+
+			It("exits 1 with --strict", func() {
+				flyCmd := exec.Command(flyPath, "validate-task", "-c", taskConfigPath, "--strict")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+
+		Context("when the task config is invalid", func() {
+			BeforeEach(func() {
+				writeConfig(`---
+platform: linux
+
+run: {}
+`)
+			})
+
+			It("prints the problems as json and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "validate-task", "-c", taskConfigPath, "--json")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				var result struct {
+					Valid    bool `json:"valid"`
+					Problems []struct {
+						Severity string `json:"severity"`
+						Check    string `json:"check"`
+					} `json:"problems"`
+				}
+
+				err = json.Unmarshal(sess.Out.Contents(), &result)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Valid).To(BeFalse())
+
+				checks := []string{}
+				for _, problem := range result.Problems {
+					checks = append(checks, problem.Check)
+				}
+
+				Expect(checks).To(ContainElement("invalid-config"))
+			})
+		})
+	})
+})
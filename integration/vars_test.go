@@ -0,0 +1,80 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("vars", func() {
+		var tmpdir string
+		var configPath string
+		var varsPath string
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir("", "fly-vars")
+			Expect(err).NotTo(HaveOccurred())
+
+			configPath = filepath.Join(tmpdir, "pipeline.yml")
+			varsPath = filepath.Join(tmpdir, "vars.yml")
+
+			err = ioutil.WriteFile(configPath, []byte(`# vars:
+#   branch: master
+#   private-key:
+
+resources:
+- name: some-resource
+  type: git
+  source:
+    uri: https://{{host}}/repo.git
+    branch: {{branch}}
+    password: {{password}}
+    private_key: {{private-key}}
+`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(varsPath, []byte("password: secret\nunused: value\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpdir)
+		})
+
+		It("lists the variables referenced by the pipeline and where they come from", func() {
+			flyCmd := exec.Command(flyPath, "vars", "-c", configPath, "-l", varsPath, "-v", "host=example.com")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			Expect(sess.Out).To(PrintTable(ui.Table{
+				Headers: ui.TableRow{
+					{Contents: "name", Color: color.New(color.Bold)},
+					{Contents: "source", Color: color.New(color.Bold)},
+				},
+				Data: []ui.TableRow{
+					{{Contents: "host"}, {Contents: "flag"}},
+					{{Contents: "branch"}, {Contents: "default"}},
+					{{Contents: "password"}, {Contents: "file " + varsPath}},
+					{{Contents: "private-key"}, {Contents: "missing", Color: color.New(color.FgRed)}},
+				},
+			}))
+
+			Expect(sess.Err).To(gbytes.Say("variable `unused` is provided but never used"))
+		})
+	})
+})
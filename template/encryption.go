@@ -0,0 +1,131 @@
+package template
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	encryptedHeader = "fly-encrypted-vars:v1"
+
+	saltSize  = 16
+	nonceSize = 24
+	keySize   = 32
+
+	lineLength = 64
+)
+
+var ErrNoPassphrase = errors.New("no passphrase given for encrypted variables")
+var ErrDecryptionFailed = errors.New("could not decrypt variables; wrong passphrase or corrupted file")
+
+// IsEncrypted determines whether the contents of a variables file were
+// produced by EncryptVariables.
+func IsEncrypted(contents []byte) bool {
+	return bytes.HasPrefix(contents, []byte(encryptedHeader+"\n"))
+}
+
+// ReadKeyFile reads a passphrase from a file, ignoring surrounding
+// whitespace.
+func ReadKeyFile(path string) ([]byte, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase := bytes.TrimSpace(contents)
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("key file '%s' is empty", path)
+	}
+
+	return passphrase, nil
+}
+
+// EncryptVariables seals the contents with NaCl secretbox, using a key
+// derived from the passphrase with scrypt.
+func EncryptVariables(plaintext []byte, passphrase []byte) ([]byte, error) {
+	var salt [saltSize]byte
+	_, err := io.ReadFull(rand.Reader, salt[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [nonceSize]byte
+	_, err = io.ReadFull(rand.Reader, nonce[:])
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, salt[:])
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := append(salt[:], nonce[:]...)
+	sealed = secretbox.Seal(sealed, plaintext, &nonce, key)
+
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+
+	encrypted := bytes.NewBufferString(encryptedHeader + "\n")
+	for len(encoded) > lineLength {
+		encrypted.WriteString(encoded[:lineLength] + "\n")
+		encoded = encoded[lineLength:]
+	}
+
+	encrypted.WriteString(encoded + "\n")
+
+	return encrypted.Bytes(), nil
+}
+
+// DecryptVariables opens contents produced by EncryptVariables.
+func DecryptVariables(contents []byte, passphrase []byte) ([]byte, error) {
+	if !IsEncrypted(contents) {
+		return nil, errors.New("variables are not encrypted")
+	}
+
+	encoded := strings.Join(strings.Fields(string(contents[len(encryptedHeader):])), "")
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed encrypted variables: %s", err)
+	}
+
+	if len(sealed) < saltSize+nonceSize+secretbox.Overhead {
+		return nil, errors.New("malformed encrypted variables: too short")
+	}
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed[saltSize:saltSize+nonceSize])
+
+	key, err := deriveKey(passphrase, sealed[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, ok := secretbox.Open(nil, sealed[saltSize+nonceSize:], &nonce, key)
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+
+	return plaintext, nil
+}
+
+func deriveKey(passphrase []byte, salt []byte) (*[keySize]byte, error) {
+	derived, err := scrypt.Key(passphrase, salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	var key [keySize]byte
+	copy(key[:], derived)
+
+	return &key, nil
+}
@@ -0,0 +1,100 @@
+package template_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/template"
+)
+
+var _ = Describe("Encrypted variables", func() {
+	plaintext := []byte("password: hunter2\n")
+
+	It("can be decrypted with the passphrase they were encrypted with", func() {
+		encrypted, err := template.EncryptVariables(plaintext, []byte("some-passphrase"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(template.IsEncrypted(encrypted)).To(BeTrue())
+		Expect(string(encrypted)).NotTo(ContainSubstring("hunter2"))
+
+		decrypted, err := template.DecryptVariables(encrypted, []byte("some-passphrase"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decrypted).To(Equal(plaintext))
+	})
+
+	It("cannot be decrypted with another passphrase", func() {
+		encrypted, err := template.EncryptVariables(plaintext, []byte("some-passphrase"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = template.DecryptVariables(encrypted, []byte("some-other-passphrase"))
+		Expect(err).To(Equal(template.ErrDecryptionFailed))
+	})
+
+	It("does not treat plain variables as encrypted", func() {
+		Expect(template.IsEncrypted(plaintext)).To(BeFalse())
+
+		_, err := template.DecryptVariables(plaintext, []byte("some-passphrase"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("loading them from a file", func() {
+		var tmpdir string
+		var varsPath string
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir("", "fly-encrypted-vars")
+			Expect(err).NotTo(HaveOccurred())
+
+			encrypted, err := template.EncryptVariables(plaintext, []byte("some-passphrase"))
+			Expect(err).NotTo(HaveOccurred())
+
+			varsPath = filepath.Join(tmpdir, "vars.yml")
+
+			err = ioutil.WriteFile(varsPath, encrypted, 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpdir)
+		})
+
+		It("decrypts them with the given passphrase", func() {
+			variables, err := template.LoadVariablesFromFile(varsPath, []byte("some-passphrase"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(Equal(template.Variables{"password": "hunter2"}))
+		})
+
+		It("returns an error when there is no passphrase", func() {
+			_, err := template.LoadVariablesFromFile(varsPath, nil)
+			Expect(err).To(Equal(template.ErrNoPassphrase))
+		})
+
+		It("ignores the environment", func() {
+			os.Setenv("FLY_VARS_PASSPHRASE", "some-passphrase")
+			defer os.Unsetenv("FLY_VARS_PASSPHRASE")
+
+			_, err := template.LoadVariablesFromFile(varsPath, nil)
+			Expect(err).To(Equal(template.ErrNoPassphrase))
+		})
+	})
+
+	It("reads a passphrase from a key file, ignoring surrounding whitespace", func() {
+		tmpdir, err := ioutil.TempDir("", "fly-key-file")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(tmpdir)
+
+		keyPath := filepath.Join(tmpdir, "key")
+
+		err = ioutil.WriteFile(keyPath, []byte("some-passphrase\n"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		passphrase, err := template.ReadKeyFile(keyPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(passphrase).To(Equal([]byte("some-passphrase")))
+	})
+})
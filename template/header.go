@@ -0,0 +1,75 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const headerMarker = "# vars:"
+
+// ParseHeader reads the variables declared by a comment block at the top of
+// the template:
+//
+//	# vars:
+//	#   branch: master
+//	#   github-token:
+//
+// Variables declared with a value get it as their default; the others are
+// required.
+func ParseHeader(content []byte) (Variables, []string, error) {
+	defaults := Variables{}
+	required := []string{}
+
+	lines := strings.Split(string(content), "\n")
+
+	i := 0
+	for i < len(lines) && isBlankOrDocumentStart(lines[i]) {
+		i++
+	}
+
+	if i == len(lines) || strings.TrimSpace(lines[i]) != headerMarker {
+		return defaults, required, nil
+	}
+
+	declarations := []string{}
+	for _, line := range lines[i+1:] {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#  ") {
+			break
+		}
+
+		declarations = append(declarations, strings.TrimPrefix(trimmed, "#"))
+	}
+
+	var header yaml.MapSlice
+	err := yaml.Unmarshal([]byte(strings.Join(declarations, "\n")), &header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid vars header: %s", err)
+	}
+
+	for _, item := range header {
+		name, ok := item.Key.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid vars header: non-string variable name %v", item.Key)
+		}
+
+		if item.Value == nil {
+			required = append(required, name)
+			continue
+		}
+
+		defaults[name], err = NormalizeValue(item.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid vars header: %s", err)
+		}
+	}
+
+	return defaults, required, nil
+}
+
+func isBlankOrDocumentStart(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || trimmed == "---"
+}
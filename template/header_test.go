@@ -0,0 +1,59 @@
+package template_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/template"
+)
+
+var _ = Describe("ParseHeader", func() {
+	It("reads defaults and required variables from the vars header", func() {
+		defaults, required, err := template.ParseHeader([]byte(`---
+# vars:
+#   branch: master
+#   tags: [some-tag]
+#   github-token:
+#   private-key:
+
+# some other comment
+resources: []
+`))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(defaults).To(Equal(template.Variables{
+			"branch": "master",
+			"tags":   []interface{}{"some-tag"},
+		}))
+		Expect(required).To(Equal([]string{"github-token", "private-key"}))
+	})
+
+	It("stops at the first comment that is not part of the header", func() {
+		defaults, required, err := template.ParseHeader([]byte(`# vars:
+#   branch: master
+# this pipeline builds things
+resources: []
+`))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(defaults).To(Equal(template.Variables{"branch": "master"}))
+		Expect(required).To(BeEmpty())
+	})
+
+	It("returns nothing when there is no header", func() {
+		defaults, required, err := template.ParseHeader([]byte(`# just a comment
+resources: []
+`))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(defaults).To(BeEmpty())
+		Expect(required).To(BeEmpty())
+	})
+
+	It("returns an error when the header is not valid YAML", func() {
+		_, _, err := template.ParseHeader([]byte(`# vars:
+#   branch: [master
+`))
+		Expect(err).To(HaveOccurred())
+	})
+})
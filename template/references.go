@@ -0,0 +1,54 @@
+package template
+
+import "strings"
+
+// Reference is a variable referenced by a template.
+type Reference struct {
+	Name       string
+	Default    interface{}
+	HasDefault bool
+
+	// Parenthesized references, i.e. ((var)), are left in place when the
+	// variable is not set, e.g. for a credential manager to fill in.
+	Parenthesized bool
+}
+
+// Variable returns the name of the top-level variable that the reference
+// looks up, e.g. db for db.password.
+func (reference Reference) Variable() string {
+	return strings.SplitN(reference.Name, ".", 2)[0]
+}
+
+// References lists the variables referenced by the template, in the order
+// they first appear. A variable counts as having a default if any of its
+// references gives one, and as parenthesized only if all of them are.
+func References(content []byte) []Reference {
+	references := []Reference{}
+	indices := map[string]int{}
+
+	for _, loc := range templateFormatRegex.FindAllSubmatchIndex(content, -1) {
+		if isShellArithmetic(content, loc[0]) {
+			continue
+		}
+
+		reference := referenceAt(content, loc)
+
+		i, seen := indices[reference.Name]
+		if !seen {
+			indices[reference.Name] = len(references)
+			references = append(references, reference)
+			continue
+		}
+
+		if reference.HasDefault && !references[i].HasDefault {
+			references[i].Default = reference.Default
+			references[i].HasDefault = true
+		}
+
+		if !reference.Parenthesized {
+			references[i].Parenthesized = false
+		}
+	}
+
+	return references
+}
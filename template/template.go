@@ -1,29 +1,202 @@
 package template
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
+	"gopkg.in/yaml.v2"
 )
 
-var templateFormatRegex = regexp.MustCompile(`\{\{([-\w\p{L}]+)\}\}`)
+var templateFormatRegex = regexp.MustCompile(`\{\{([-\w\p{L}.]+)(?::-([^}]*))?\}\}|\(\(([-\w\p{L}.]+)(?::-([^)]*))?\)\)`)
 
+// Evaluate replaces {{var}} and ((var)) references in the content. A
+// reference making up a whole value, or starting a word as it always could,
+// is replaced by its value encoded as YAML, so that lists and maps can be
+// templated in; a reference following text inside a string is replaced by
+// the plain value. References may give a default for when the variable is
+// not set, e.g. {{branch:-master}}; a ((var)) reference to a variable that is
+// not set is left as it is.
 func Evaluate(content []byte, variables Variables) ([]byte, error) {
 	var variableErrors error
 
-	return templateFormatRegex.ReplaceAllFunc(content, func(match []byte) []byte {
-		key := string(templateFormatRegex.FindSubmatch(match)[1])
+	result := []byte{}
+	last := 0
+
+	for _, loc := range templateFormatRegex.FindAllSubmatchIndex(content, -1) {
+		start, end := loc[0], loc[1]
+		match := content[start:end]
+
+		result = append(result, content[last:start]...)
+		last = end
+
+		if isShellArithmetic(content, start) {
+			result = append(result, match...)
+			continue
+		}
+
+		reference := referenceAt(content, loc)
+		key := reference.Name
+
+		value, found := variables.Lookup(key)
+		if !found && reference.HasDefault {
+			value, found = reference.Default, true
+		}
+
+		if !found && reference.Parenthesized {
+			result = append(result, match...)
+			continue
+		}
 
-		value, found := variables[key]
 		if !found {
 			variableErrors = multierror.Append(variableErrors, fmt.Errorf("unbound variable in template: '%s'", key))
-			return match
+			result = append(result, match...)
+			continue
+		}
+
+		var rendered []byte
+		var err error
+		if isWholeValue(content, start, end) || !followsText(content, start) {
+			rendered, err = encodeValue(value)
+		} else {
+			rendered, err = interpolateValue(value)
+		}
+		if err != nil {
+			variableErrors = multierror.Append(variableErrors, fmt.Errorf("invalid value for variable '%s': %s", key, err))
+			result = append(result, match...)
+			continue
+		}
+
+		result = append(result, rendered...)
+	}
+
+	return append(result, content[last:]...), variableErrors
+}
+
+// isShellArithmetic detects shell arithmetic such as $((1+2)), which is
+// left alone.
+func isShellArithmetic(content []byte, start int) bool {
+	return content[start] == '(' && start > 0 && content[start-1] == '$'
+}
+
+func referenceAt(content []byte, loc []int) Reference {
+	nameGroup, defaultGroup := 2, 4
+	parenthesized := loc[nameGroup] == -1
+	if parenthesized {
+		nameGroup, defaultGroup = 6, 8
+	}
+
+	reference := Reference{
+		Name:          string(content[loc[nameGroup]:loc[nameGroup+1]]),
+		Parenthesized: parenthesized,
+	}
+
+	if loc[defaultGroup] != -1 {
+		reference.Default = parseDefault(string(content[loc[defaultGroup]:loc[defaultGroup+1]]))
+		reference.HasDefault = true
+	}
+
+	return reference
+}
+
+func parseDefault(raw string) interface{} {
+	var value interface{}
+
+	err := yaml.Unmarshal([]byte(raw), &value)
+	if err != nil || value == nil {
+		return raw
+	}
+
+	normalized, err := NormalizeValue(value)
+	if err != nil {
+		return raw
+	}
+
+	return normalized
+}
+
+// isWholeValue determines whether the reference at content[start:end] stands
+// on its own as a YAML value (or key), as opposed to being part of a longer
+// string.
+func isWholeValue(content []byte, start int, end int) bool {
+	lineStart := bytes.LastIndexByte(content[:start], '\n') + 1
+	before := content[lineStart:start]
+	trimmedBefore := bytes.TrimRight(before, " \t")
+
+	lineEnd := bytes.IndexByte(content[end:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(content) - end
+	}
+
+	after := content[end : end+lineEnd]
+	trimmedAfter := bytes.TrimLeft(after, " \t")
+
+	separatedBefore := len(trimmedBefore) < len(before)
+	separatedAfter := len(trimmedAfter) < len(after)
+
+	startsValue := false
+	if len(bytes.TrimSpace(before)) == 0 {
+		startsValue = true
+	} else {
+		switch trimmedBefore[len(trimmedBefore)-1] {
+		case '[', '{', ',':
+			startsValue = true
+		case ':', '-':
+			startsValue = separatedBefore
 		}
+	}
 
-		saveValue, _ := json.Marshal(value)
+	endsValue := false
+	if len(bytes.TrimSpace(after)) == 0 {
+		endsValue = true
+	} else {
+		switch trimmedAfter[0] {
+		case ']', '}', ',', ':':
+			endsValue = true
+		case '#':
+			endsValue = separatedAfter
+		}
+	}
+
+	return startsValue && endsValue
+}
+
+// followsText determines whether the reference at content[start:] is
+// preceded by text within the same word, e.g. https://{{host}}, rather than
+// starting the word, e.g. {{key}}={{value}}.
+func followsText(content []byte, start int) bool {
+	wordStart := bytes.LastIndexAny(content[:start], " \t\n[,") + 1
+
+	reference := templateFormatRegex.FindIndex(content[wordStart:])
+	return reference == nil || reference[0] != 0
+}
+
+func encodeValue(value interface{}) ([]byte, error) {
+	normalized, err := NormalizeValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(normalized)
+}
+
+func interpolateValue(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		// these would end the string, or the YAML value around it
+		if strings.ContainsAny(v, "\n\"'") {
+			return nil, fmt.Errorf("cannot interpolate a value containing newlines or quotes into a string; reference it as a whole value instead")
+		}
 
-		return []byte(saveValue)
-	}), variableErrors
+		return []byte(v), nil
+	case nil:
+		return []byte{}, nil
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+		return nil, fmt.Errorf("cannot interpolate a list or map into a string")
+	default:
+		return []byte(fmt.Sprintf("%v", v)), nil
+	}
 }
@@ -76,6 +76,164 @@ var _ = Describe("Template", func() {
 		Expect(result).To(Equal([]byte(`"this\nhas\nmany\nlines"`)))
 	})
 
+	It("can template structured values into a byte slice", func() {
+		byteSlice := []byte("tags: {{tags}}\nsource: {{source}}\nprivate: {{private}}\ninstances: {{instances}}")
+		variables := template.Variables{
+			"tags":      []interface{}{"a", "b"},
+			"source":    map[string]interface{}{"uri": "https://example.com"},
+			"private":   true,
+			"instances": 3,
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal(`tags: ["a","b"]
+source: {"uri":"https://example.com"}
+private: true
+instances: 3`))
+	})
+
+	It("can template maps unmarshalled from YAML into a byte slice", func() {
+		byteSlice := []byte("{{source}}")
+		variables := template.Variables{
+			"source": map[interface{}]interface{}{"uri": "https://example.com"},
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal(`{"uri":"https://example.com"}`))
+	})
+
+	It("interpolates values into the middle of a string", func() {
+		byteSlice := []byte("uri: https://{{host}}:{{port}}/repo.git")
+		variables := template.Variables{
+			"host": "example.com",
+			"port": 8443,
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal("uri: https://example.com:8443/repo.git"))
+	})
+
+	It("keeps the text of numbers loaded from a file", func() {
+		byteSlice := []byte("version: {{v}}")
+		variables, err := template.ParseVariables([]byte("v: 1.10"))
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal(`version: "1.10"`))
+	})
+
+	It("encodes whole values inside flow sequences and mappings", func() {
+		byteSlice := []byte("args: [-c, {{script}}]\nsource: {uri: {{uri}}} # some comment")
+		variables := template.Variables{
+			"script": "echo hi",
+			"uri":    "https://example.com",
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal(`args: [-c, "echo hi"]
+source: {uri: "https://example.com"} # some comment`))
+	})
+
+	It("raises an error when interpolating a map into a string", func() {
+		byteSlice := []byte("uri: https://{{source}}/repo.git")
+		variables := template.Variables{
+			"source": map[string]interface{}{"host": "example.com"},
+		}
+
+		_, err := template.Evaluate(byteSlice, variables)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid value for variable 'source'"))
+	})
+
+	It("supports the ((var)) syntax", func() {
+		byteSlice := []byte("branch: ((branch))\nuri: https://((host))/repo.git")
+		variables := template.Variables{
+			"branch": "master",
+			"host":   "example.com",
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal("branch: \"master\"\nuri: https://example.com/repo.git"))
+	})
+
+	It("leaves a ((var)) reference to a variable that is not set in place", func() {
+		byteSlice := []byte("password: ((vault-password))\nuri: https://((host))/repo.git")
+		variables := template.Variables{
+			"host": "example.com",
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal("password: ((vault-password))\nuri: https://example.com/repo.git"))
+	})
+
+	It("raises an error when interpolating a value with newlines or quotes into a string", func() {
+		byteSlice := []byte("uri: https://{{host}}/repo.git\nbranch: some-{{branch}}")
+		variables := template.Variables{
+			"host":   "example.com\nevil: true",
+			"branch": `with"quote`,
+		}
+
+		_, err := template.Evaluate(byteSlice, variables)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid value for variable 'host': cannot interpolate a value containing newlines or quotes"))
+		Expect(err.Error()).To(ContainSubstring("invalid value for variable 'branch': cannot interpolate a value containing newlines or quotes"))
+	})
+
+	It("leaves shell arithmetic alone", func() {
+		byteSlice := []byte("args: [-c, 'echo $((count))']")
+		variables := template.Variables{
+			"count": 1,
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal("args: [-c, 'echo $((count))']"))
+	})
+
+	It("looks up dotted paths in nested variables", func() {
+		byteSlice := []byte("password: {{db.password}}\nuri: postgres://{{db.user}}@{{db.host}}")
+		variables := template.Variables{
+			"db": map[string]interface{}{
+				"user":     "admin",
+				"password": "hunter2",
+				"host":     "db.example.com",
+			},
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal("password: \"hunter2\"\nuri: postgres://admin@db.example.com"))
+	})
+
+	It("raises an error for a dotted path that does not exist", func() {
+		byteSlice := []byte("{{db.password}}")
+		variables := template.Variables{
+			"db": map[string]interface{}{},
+		}
+
+		_, err := template.Evaluate(byteSlice, variables)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unbound variable in template: 'db.password'"))
+	})
+
+	It("uses the default given by the reference when the variable is not set", func() {
+		byteSlice := []byte("branch: {{branch:-master}}\ndepth: ((depth:-1))\nuri: https://{{host:-example.com}}/repo.git")
+		variables := template.Variables{
+			"host": "internal.example.com",
+		}
+
+		result, err := template.Evaluate(byteSlice, variables)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(result)).To(Equal("branch: \"master\"\ndepth: 1\nuri: https://internal.example.com/repo.git"))
+	})
+
 	It("raises an error for each variable that is undefined", func() {
 		byteSlice := []byte("{{not-specified-one}}{{not-specified-two}}")
 		variables := template.Variables{}
@@ -98,3 +256,40 @@ var _ = Describe("Template", func() {
 		Expect(result).To(Equal([]byte("{{}")))
 	})
 })
+
+var _ = Describe("References", func() {
+	It("lists each variable referenced by the template once, in order", func() {
+		references := template.References([]byte(`
+type: {{type}}
+source:
+  uri: https://((host))/repo.git
+  branch: {{branch:-master}}
+  password: {{db.password}}
+  other-uri: https://((host))/other.git
+  script: echo $((count))
+`))
+
+		Expect(references).To(Equal([]template.Reference{
+			{Name: "type"},
+			{Name: "host", Parenthesized: true},
+			{Name: "branch", Default: "master", HasDefault: true},
+			{Name: "db.password"},
+		}))
+	})
+
+	It("only counts a variable as parenthesized if every reference to it is", func() {
+		references := template.References([]byte(`
+uri: https://((host))/repo.git
+other-uri: https://{{host}}/other.git
+`))
+
+		Expect(references).To(Equal([]template.Reference{
+			{Name: "host"},
+		}))
+	})
+
+	It("gives the top-level variable of a dotted reference", func() {
+		Expect(template.Reference{Name: "db.password"}.Variable()).To(Equal("db"))
+		Expect(template.Reference{Name: "branch"}.Variable()).To(Equal("branch"))
+	})
+})
@@ -1,13 +1,22 @@
 package template
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
-type Variables map[string]string
+// Variables maps variable names to values of any YAML type, i.e. strings,
+// numbers, booleans, lists and maps.
+type Variables map[string]interface{}
 
+// Merge returns the variables of both sets, with values from other taking
+// precedence. Maps present in both sets are merged recursively.
 func (v Variables) Merge(other Variables) Variables {
 	merged := Variables{}
 
@@ -16,24 +25,243 @@ func (v Variables) Merge(other Variables) Variables {
 	}
 
 	for key, value := range other {
+		merged[key] = mergeValues(merged[key], value)
+	}
+
+	return merged
+}
+
+func mergeValues(a interface{}, b interface{}) interface{} {
+	mapA, aIsMap := a.(map[string]interface{})
+	mapB, bIsMap := b.(map[string]interface{})
+	if !aIsMap || !bIsMap {
+		return b
+	}
+
+	merged := map[string]interface{}{}
+
+	for key, value := range mapA {
 		merged[key] = value
 	}
 
+	for key, value := range mapB {
+		merged[key] = mergeValues(merged[key], value)
+	}
+
 	return merged
 }
 
-func LoadVariablesFromFile(path string) (Variables, error) {
+// Lookup finds the value of a variable, following dotted paths such as
+// db.password into nested maps.
+func (v Variables) Lookup(name string) (interface{}, bool) {
+	if value, found := v[name]; found {
+		return value, true
+	}
+
+	var current interface{} = map[string]interface{}(v)
+
+	for _, segment := range strings.Split(name, ".") {
+		var found bool
+
+		switch m := current.(type) {
+		case map[string]interface{}:
+			current, found = m[segment]
+		case map[interface{}]interface{}:
+			current, found = m[segment]
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// LoadVariablesFromFile reads YAML variables from the file, decrypting it
+// with the passphrase if it was encrypted by EncryptVariables.
+func LoadVariablesFromFile(path string, passphrase []byte) (Variables, error) {
 	contents, err := ioutil.ReadFile(path)
 	if err != nil {
 		return Variables{}, err
 	}
 
-	var variables Variables
+	if IsEncrypted(contents) {
+		if len(passphrase) == 0 {
+			return Variables{}, ErrNoPassphrase
+		}
+
+		contents, err = DecryptVariables(contents, passphrase)
+		if err != nil {
+			return Variables{}, err
+		}
+	}
+
+	return ParseVariables(contents)
+}
+
+// LoadVariablesFromEnv imports the environment variables starting with the
+// prefix. The rest of the name is lowercased, and as variable names are often
+// dasherized while environment variables can not be, each is imported both
+// as it is and with its underscores turned into dashes, so
+// PREFIX_GITHUB_TOKEN sets both github_token and github-token.
+func LoadVariablesFromEnv(prefix string, environ []string) Variables {
+	variables := Variables{}
+
+	for _, env := range environ {
+		pair := strings.SplitN(env, "=", 2)
+		if len(pair) != 2 || !strings.HasPrefix(pair[0], prefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(pair[0], prefix)
+		if name == "" {
+			continue
+		}
+
+		name = strings.ToLower(name)
+
+		variables[name] = pair[1]
+		variables[EnvVariableAlias(name)] = pair[1]
+	}
+
+	return variables
+}
+
+// EnvVariableAlias is the dasherized name that a variable imported from the
+// environment is also set as.
+func EnvVariableAlias(name string) string {
+	return strings.Replace(name, "_", "-", -1)
+}
+
+// LoadVariablesFromCommand runs the command with the shell, or cmd on
+// Windows, and reads YAML variables from its stdout.
+func LoadVariablesFromCommand(command string) (Variables, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return Variables{}, err
+	}
+
+	return ParseVariables(output)
+}
+
+// ParseVariables reads variables from YAML. Lists and maps are kept as they
+// are, but scalars are kept as they were written, so that e.g. a version of
+// 1.10 is not turned into the number 1.1.
+func ParseVariables(contents []byte) (Variables, error) {
+	var raw map[string]writtenValue
 
-	err = yaml.Unmarshal(contents, &variables)
+	err := yaml.Unmarshal(contents, &raw)
 	if err != nil {
 		return Variables{}, err
 	}
 
+	variables := Variables{}
+	for key, value := range raw {
+		variables[key] = value.value
+	}
+
 	return variables, nil
 }
+
+// writtenValue decodes a YAML value, keeping the text of its scalars.
+type writtenValue struct {
+	value interface{}
+}
+
+func (v *writtenValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var scalar string
+	if err := unmarshal(&scalar); err == nil {
+		v.value = scalar
+		return nil
+	}
+
+	var list []writtenValue
+	if err := unmarshal(&list); err == nil {
+		values := make([]interface{}, len(list))
+		for i, value := range list {
+			values[i] = value.value
+		}
+
+		v.value = values
+		return nil
+	}
+
+	var mapping map[string]writtenValue
+	if err := unmarshal(&mapping); err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	for key, value := range mapping {
+		values[key] = value.value
+	}
+
+	v.value = values
+	return nil
+}
+
+// NormalizeValue converts the maps produced by unmarshalling YAML into maps
+// keyed by strings, so that the value can be merged and encoded as JSON.
+func NormalizeValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := map[string]interface{}{}
+
+		for key, subValue := range v {
+			stringKey, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("non-string key in variables: %v", key)
+			}
+
+			normalizedValue, err := NormalizeValue(subValue)
+			if err != nil {
+				return nil, err
+			}
+
+			normalized[stringKey] = normalizedValue
+		}
+
+		return normalized, nil
+
+	case map[string]interface{}:
+		normalized := map[string]interface{}{}
+
+		for key, subValue := range v {
+			normalizedValue, err := NormalizeValue(subValue)
+			if err != nil {
+				return nil, err
+			}
+
+			normalized[key] = normalizedValue
+		}
+
+		return normalized, nil
+
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+
+		for i, subValue := range v {
+			normalizedValue, err := NormalizeValue(subValue)
+			if err != nil {
+				return nil, err
+			}
+
+			normalized[i] = normalizedValue
+		}
+
+		return normalized, nil
+
+	default:
+		return value, nil
+	}
+}
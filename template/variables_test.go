@@ -59,11 +59,70 @@ var _ = Describe("Variables", func() {
 			}))
 
 		})
+
+		It("merges nested maps", func() {
+			a := template.Variables{
+				"source": map[string]interface{}{
+					"uri":    "https://example.com",
+					"branch": "master",
+				},
+			}
+			b := template.Variables{
+				"source": map[string]interface{}{
+					"branch": "develop",
+				},
+			}
+
+			result := a.Merge(b)
+
+			Expect(result).To(Equal(template.Variables{
+				"source": map[string]interface{}{
+					"uri":    "https://example.com",
+					"branch": "develop",
+				},
+			}))
+		})
+
+		It("replaces values that are not both maps", func() {
+			a := template.Variables{
+				"tags": []interface{}{"a"},
+			}
+			b := template.Variables{
+				"tags": []interface{}{"b"},
+			}
+
+			result := a.Merge(b)
+
+			Expect(result).To(Equal(template.Variables{
+				"tags": []interface{}{"b"},
+			}))
+		})
+	})
+
+	Describe("looking up a variable", func() {
+		variables := template.Variables{
+			"db": map[string]interface{}{
+				"credentials": map[interface{}]interface{}{
+					"password": "hunter2",
+				},
+			},
+		}
+
+		It("follows dotted paths into nested maps", func() {
+			value, found := variables.Lookup("db.credentials.password")
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("hunter2"))
+		})
+
+		It("does not find paths through values that are not maps", func() {
+			_, found := variables.Lookup("db.credentials.password.length")
+			Expect(found).To(BeFalse())
+		})
 	})
 
 	Describe("loading variables from a file", func() {
 		It("can load them from a file", func() {
-			variables, err := template.LoadVariablesFromFile("fixtures/vars.yml")
+			variables, err := template.LoadVariablesFromFile("fixtures/vars.yml", nil)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(variables).To(Equal(template.Variables{
 				"hello": "world",
@@ -71,13 +130,72 @@ var _ = Describe("Variables", func() {
 
 		})
 
+		It("keeps lists and maps, and scalars as they were written", func() {
+			variables, err := template.LoadVariablesFromFile("fixtures/structured_vars.yml", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(Equal(template.Variables{
+				"tags":      []interface{}{"some-tag", "some-other-tag"},
+				"private":   "true",
+				"instances": "3",
+				"version":   "1.10",
+				"source": map[string]interface{}{
+					"uri":    "https://example.com/repo.git",
+					"branch": "master",
+				},
+			}))
+		})
+
+		It("loads nested maps, which used to be rejected", func() {
+			variables, err := template.LoadVariablesFromFile("fixtures/invalid_vars.yml", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(Equal(template.Variables{
+				"nested": map[string]interface{}{
+					"keys": "are bad",
+				},
+			}))
+		})
+
 		It("returns an error if the file does not exist", func() {
-			_, err := template.LoadVariablesFromFile("fixtures/missing.yml")
+			_, err := template.LoadVariablesFromFile("fixtures/missing.yml", nil)
 			Expect(err).To(HaveOccurred())
 		})
 
 		It("returns an error if the file is in an invalid format", func() {
-			_, err := template.LoadVariablesFromFile("fixtures/invalid_vars.yml")
+			_, err := template.LoadVariablesFromFile("fixtures/list_vars.yml", nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("loading variables from the environment", func() {
+		It("imports the variables with the prefix, lowercased, both as they are and dasherized", func() {
+			variables := template.LoadVariablesFromEnv("CI_", []string{
+				"CI_GITHUB_TOKEN=some-token",
+				"CI_BRANCH=master",
+				"CI_=ignored",
+				"HOME=/home/ci",
+			})
+
+			Expect(variables).To(Equal(template.Variables{
+				"github_token": "some-token",
+				"github-token": "some-token",
+				"branch":       "master",
+			}))
+		})
+	})
+
+	Describe("loading variables from a command", func() {
+		It("reads YAML from the command's stdout", func() {
+			variables, err := template.LoadVariablesFromCommand(`printf 'token: some-token\ntags: [a, b]\n'`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(variables).To(Equal(template.Variables{
+				"token": "some-token",
+				"tags":  []interface{}{"a", "b"},
+			}))
+		})
+
+		It("returns an error if the command fails", func() {
+			_, err := template.LoadVariablesFromCommand("echo 'token: some-token'; exit 1")
 			Expect(err).To(HaveOccurred())
 		})
 	})